@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// GoFunc wraps a plain Go function of the state vector as a Func - e.g. to use an analytic
+// solution that is awkward to build symbolically as a reference solution.  Partial derivatives
+// are approximated with central differences.
+type GoFunc func(x []float64) float64
+
+func (f GoFunc) Val(x []float64) float64 { return f(x) }
+
+func (f GoFunc) Partial(v Variable) Func {
+	return GoFunc(func(x []float64) float64 {
+		xv := append([]float64{}, x...)
+		h := 1e-5 * math.Max(1, math.Abs(x[int(v)]))
+		xv[int(v)] = x[int(v)] + h
+		fwd := f(xv)
+		xv[int(v)] = x[int(v)] - h
+		return (fwd - f(xv)) / (2 * h)
+	})
+}
+
+func (f GoFunc) Simplify() Func { return f }
+func (f GoFunc) String() string { return "GoFunc(???)" }
+
+// ErrorNorms summarizes the error of a computed solution against a reference solution over a set
+// of sample points.  Norms are discrete - i.e. root mean square (L2, H1) and max (LInf) over the
+// points.  H1 is the H1 seminorm (the L2 norm of the error's gradient) and is NaN if it wasn't
+// computed.  The relative errors are normalized by the corresponding norm of the reference.
+type ErrorNorms struct {
+	Name    string
+	N       int
+	L2      float64
+	LInf    float64
+	H1      float64
+	RelL2   float64
+	RelLInf float64
+}
+
+// ErrorNorms computes the error of u against the exact solution at the given points (in
+// n.Vars order).  The H1 seminorm is computed using the partial derivatives w.r.t. vars - if no
+// vars are given, H1 is NaN.  The network's weights must already be set (e.g. by training).
+func (n *Network) ErrorNorms(u, exact Func, pts [][]float64, vars ...Variable) ErrorNorms {
+	diff := Sum{u, Negative(exact)}
+	var grads []Func
+	for _, v := range vars {
+		grads = append(grads, diff.Partial(v))
+	}
+
+	got, want := n.EvalBatch(u, pts), n.EvalBatch(exact, pts)
+	var gradVals [][]float64
+	for _, g := range grads {
+		gradVals = append(gradVals, n.EvalBatch(g, pts))
+	}
+
+	var acc errAccumulator
+	for k := range pts {
+		gradsq := 0.0
+		for _, vals := range gradVals {
+			gradsq += vals[k] * vals[k]
+		}
+		acc.add(got[k], want[k], gradsq)
+	}
+	norms := acc.norms()
+	if len(vars) == 0 {
+		norms.H1 = math.NaN()
+	}
+	return norms
+}
+
+// DataErrorNorms computes the error of u against reference values (e.g. from measurements or a
+// high fidelity simulation) at the given points (in n.Vars order).  H1 is always NaN.
+func (n *Network) DataErrorNorms(u Func, pts [][]float64, ref []float64) ErrorNorms {
+	if len(pts) != len(ref) {
+		panic(fmt.Sprintf("got %v points but %v reference values", len(pts), len(ref)))
+	}
+	var acc errAccumulator
+	for i, got := range n.EvalBatch(u, pts) {
+		acc.add(got, ref[i], 0)
+	}
+	norms := acc.norms()
+	norms.H1 = math.NaN()
+	return norms
+}
+
+type errAccumulator struct {
+	n                   int
+	sumsq, refsq        float64
+	max, refmax, gradsq float64
+}
+
+func (a *errAccumulator) add(got, want, gradsq float64) {
+	e := math.Abs(got - want)
+	a.n++
+	a.sumsq += e * e
+	a.refsq += want * want
+	a.max = math.Max(a.max, e)
+	a.refmax = math.Max(a.refmax, math.Abs(want))
+	a.gradsq += gradsq
+}
+
+func (a *errAccumulator) norms() ErrorNorms {
+	if a.n == 0 {
+		nan := math.NaN()
+		return ErrorNorms{L2: nan, LInf: nan, H1: nan, RelL2: nan, RelLInf: nan}
+	}
+	nf := float64(a.n)
+	norms := ErrorNorms{
+		N:    a.n,
+		L2:   math.Sqrt(a.sumsq / nf),
+		LInf: a.max,
+		H1:   math.Sqrt(a.gradsq / nf),
+	}
+	norms.RelL2 = norms.L2 / math.Sqrt(a.refsq/nf)
+	norms.RelLInf = norms.LInf / a.refmax
+	return norms
+}
+
+// WriteErrorNorms writes a summary table of the given error norms.
+func WriteErrorNorms(w io.Writer, norms []ErrorNorms) error {
+	if _, err := fmt.Fprintf(w, "%-10v %6v %12v %12v %12v %12v %12v\n", "field", "points", "L2", "Linf", "H1-semi", "rel-L2", "rel-Linf"); err != nil {
+		return err
+	}
+	for _, e := range norms {
+		_, err := fmt.Fprintf(w, "%-10v %6v %12.4e %12.4e %12.4e %12.4e %12.4e\n", e.Name, e.N, e.L2, e.LInf, e.H1, e.RelL2, e.RelLInf)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Errors computes the error norms of every field with an exact solution (see Setup.Exact) on an
+// evaluation grid with res points along each input dimension (or EvalRes if res is zero).
+func (s *Setup) Errors(res int) []ErrorNorms {
+	vars := s.DomainVars()
+	pts := s.EvalGrid(res)
+
+	var norms []ErrorNorms
+	for _, f := range s.Fields {
+		for _, exact := range s.Exact {
+			if exact.Name == f.Name {
+				e := s.Net.ErrorNorms(f, exact, pts, vars...)
+				e.Name = f.Name
+				norms = append(norms, e)
+			}
+		}
+	}
+	return norms
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGoFunc(t *testing.T) {
+	f := GoFunc(func(x []float64) float64 { return x[0] * x[0] * x[1] })
+	x := []float64{3, 2}
+	if got := f.Val(x); got != 18 {
+		t.Errorf("val: got %v, want 18", got)
+	}
+	if got := f.Partial(0).Val(x); math.Abs(got-12) > 1e-6 {
+		t.Errorf("d/dx: got %v, want 12", got)
+	}
+	if got := f.Partial(0).Partial(1).Val(x); math.Abs(got-6) > 1e-3 {
+		t.Errorf("d2/dxdy: got %v, want 6", got)
+	}
+}
+
+func TestErrorNorms(t *testing.T) {
+	var net Network
+	_, x := net.NewInput()
+	net.initState()
+	pts := [][]float64{{0}, {1}, {2}}
+
+	// u - exact = -0.5*x so the errors are 0, 0.5 and 1
+	u := &Pow{x, Constant(2)}
+	exact := GoFunc(func(xv []float64) float64 { return xv[int(x)]*xv[int(x)] + 0.5*xv[int(x)] })
+	e := net.ErrorNorms(u, exact, pts, x)
+	check := func(name string, got, want float64) {
+		if math.Abs(got-want) > 1e-8 {
+			t.Errorf("%v: got %v, want %v", name, got, want)
+		}
+	}
+	check("L2", e.L2, math.Sqrt(1.25/3))
+	check("LInf", e.LInf, 1)
+	check("H1", e.H1, 0.5)
+	check("RelL2", e.RelL2, math.Sqrt(1.25/3)/math.Sqrt((1.5*1.5+5*5)/3.0))
+	check("RelLInf", e.RelLInf, 1.0/5)
+	if e.N != 3 {
+		t.Errorf("got N=%v, want 3", e.N)
+	}
+
+	e = net.DataErrorNorms(u, pts, []float64{0, 1, 3})
+	check("data L2", e.L2, math.Sqrt(1.0/3))
+	check("data LInf", e.LInf, 1)
+	if !math.IsNaN(e.H1) {
+		t.Errorf("data H1: got %v, want NaN", e.H1)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteErrorNorms(&buf, []ErrorNorms{{Name: "u", N: 3, L2: 0.25}}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "2.5000e-01") {
+		t.Errorf("unexpected report:\n%v", buf.String())
+	}
+}
+
+// TestSolveAccuracy is a solver quality regression test against a problem's exact solution.
+func TestSolveAccuracy(t *testing.T) {
+	s := registry["1d"].Build(Config{Resolution: 5})
+	s.Net.Train()
+	e := s.Errors(11)
+	if len(e) != 1 {
+		t.Fatalf("got %v error norms, want 1", len(e))
+	}
+	if e[0].RelL2 > 1e-4 || e[0].RelLInf > 1e-4 {
+		t.Errorf("errors too large: %+v", e[0])
+	}
+}
+
+func TestDiscontExact(t *testing.T) {
+	// the exact solution must satisfy -k*u'' = S with u(0) = u(1) = 0
+	s := registry["1d-discont"].Build(Config{Resolution: 1})
+	exact, x := s.Exact[0].Func, s.Net.Vars[0]
+	state := make([]float64, s.Net.nextVarIndex)
+	for _, xv := range []float64{0, 0.3, 1} {
+		state[int(x)] = xv
+		if got := Laplace(exact, x).Val(state); math.Abs(got+70) > 1e-12 {
+			t.Errorf("u''(%v) = %v, want -70", xv, got)
+		}
+		if want := 35 * xv * (1 - xv); math.Abs(exact.Val(state)-want) > 1e-12 {
+			t.Errorf("u(%v) = %v, want %v", xv, exact.Val(state), want)
+		}
+	}
+}
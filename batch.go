@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ValBatch evaluates f at every point (state vector) in X and stores the values in out.  Each
+// node is evaluated once for the whole batch over contiguous arrays and shared subexpressions
+// (e.g. neurons feeding several neurons of the next layer) only once in total - which is much
+// faster than calling f.Val for each point.  Nodes without a batch rule (e.g. Branch and
+// GoFunc) fall back to calling Val for each point.
+func ValBatch(f Func, X [][]float64, out []float64) {
+	b := &batch{X: X, cache: map[Func][]float64{}}
+	copy(out, b.eval(f))
+}
+
+// valBatches evaluates each of fs over the batch X sharing subexpressions between them (e.g.
+// the partial derivatives of a cost w.r.t. every weight).  The returned slices must not be
+// modified.
+func valBatches(fs []Func, X [][]float64) [][]float64 {
+	b := &batch{X: X, cache: map[Func][]float64{}}
+	vals := make([][]float64, len(fs))
+	for i, f := range fs {
+		vals[i] = b.eval(f)
+	}
+	return vals
+}
+
+// pointStates returns a copy of the network state for each training point.  If Verbose is set,
+// each point is printed following msg.
+func (n *Network) pointStates(msg string) [][]float64 {
+	X := make([][]float64, len(n.TrainData))
+	for i, pos := range n.TrainData {
+		if n.Verbose {
+			fmt.Println(msg, pos)
+		}
+		n.setPoint(i)
+		X[i] = append([]float64{}, n.state...)
+	}
+	return X
+}
+
+// EvalBatch is like EvalFunc for many points (in n.Vars order) at once - see ValBatch.  The
+// points have no observations so target variables are NaN.
+func (n *Network) EvalBatch(f Func, pts [][]float64) []float64 {
+	n.initState()
+	X := make([][]float64, len(pts))
+	for k := range pts {
+		n.setPointFrom(pts, nil, k)
+		X[k] = append([]float64{}, n.state...)
+	}
+	out := make([]float64, len(pts))
+	ValBatch(f, X, out)
+	return out
+}
+
+type batch struct {
+	X [][]float64
+	// cache holds the values of pointer nodes which may be shared between several parents.
+	cache map[Func][]float64
+}
+
+// eval returns f's values over the batch.  The returned slice may be shared and must not be
+// modified.
+func (b *batch) eval(f Func) []float64 {
+	switch f.(type) {
+	case *Neuron, *Pow, *Tanh, *Passthrough, *Param, *Integral:
+		if vals, ok := b.cache[f]; ok {
+			return vals
+		}
+		vals := b.evalNode(f)
+		b.cache[f] = vals
+		return vals
+	}
+	return b.evalNode(f)
+}
+
+// apply returns op applied to each of f's values.
+func (b *batch) apply(f Func, op func(float64) float64) []float64 {
+	in := b.eval(f)
+	out := make([]float64, len(in))
+	for k, v := range in {
+		out[k] = op(v)
+	}
+	return out
+}
+
+func (b *batch) evalNode(f Func) []float64 {
+	out := make([]float64, len(b.X))
+	switch f := f.(type) {
+	case Constant:
+		for k := range out {
+			out[k] = float64(f)
+		}
+	case Variable:
+		for k, x := range b.X {
+			out[k] = x[int(f)]
+		}
+	case Sum:
+		for _, term := range f {
+			for k, v := range b.eval(term) {
+				out[k] += v
+			}
+		}
+	case Mult:
+		// like Mult.Val, a zero factor makes the product zero even if other factors are NaN or Inf
+		zero := make([]bool, len(out))
+		for k := range out {
+			out[k] = 1
+		}
+		for _, factor := range f {
+			for k, v := range b.eval(factor) {
+				if v == 0 {
+					zero[k] = true
+				}
+				out[k] *= v
+			}
+		}
+		for k := range out {
+			if zero[k] {
+				out[k] = 0
+			}
+		}
+	case *Pow:
+		base, exp := b.eval(f.Base), b.eval(f.Exponent)
+		for k := range out {
+			out[k] = math.Pow(base[k], exp[k])
+		}
+	case Ln:
+		return b.apply(f.Func, math.Log)
+	case *Tanh:
+		return b.apply(f.Func, math.Tanh)
+	case Absolute:
+		return b.apply(f.Func, math.Abs)
+	case Sin:
+		return b.apply(f.Func, math.Sin)
+	case Cos:
+		return b.apply(f.Func, math.Cos)
+	case *Passthrough:
+		return b.eval(f.Func)
+	case *Neuron:
+		return b.eval(f.getFunc())
+	case *Param:
+		return b.eval(f.value())
+	case NamedFunc:
+		return b.eval(f.Func)
+	default:
+		for k, x := range b.X {
+			out[k] = f.Val(x)
+		}
+	}
+	return out
+}
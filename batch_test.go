@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// sameVal returns true if a and b are equal, both NaN or within rounding of each other.
+func sameVal(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b)) || math.Abs(a-b) <= 1e-12*math.Max(1, math.Abs(a))
+}
+
+func TestValBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var X [][]float64
+	for i := 0; i < 10; i++ {
+		X = append(X, []float64{4*rng.Float64() - 2, 4*rng.Float64() - 2})
+	}
+	fs := []Func{Branch(func([]float64) Func { return Mult{x, y} }), GoFunc(func(x []float64) float64 { return x[0] })}
+	for seed := int64(1); seed <= 200; seed++ {
+		fs = append(fs, genFunc(rand.New(rand.NewSource(seed)), 4, 2))
+	}
+
+	out := make([]float64, len(X))
+	for _, f := range fs {
+		ValBatch(f, X, out)
+		for k, x := range X {
+			if want := f.Val(x); !sameVal(out[k], want) {
+				t.Errorf("%v at %v: want %v, got %v", f, x, want, out[k])
+				break
+			}
+		}
+	}
+}
+
+func TestEvalBatch(t *testing.T) {
+	s := prob2d(Config{Width: 3, Depth: 1, Resolution: 4})
+	net := s.Net
+	net.RandomizeWeights(rand.New(rand.NewSource(1)))
+	u := s.Fields[0].Func
+	lap := Laplace(u, net.Vars...)
+	pts := s.EvalGrid(4)
+	for _, f := range []Func{u, lap} {
+		got := net.EvalBatch(f, pts)
+		for k, pt := range pts {
+			if want := net.EvalFunc(f, pt); !sameVal(got[k], want) {
+				t.Fatalf("at %v: want %v, got %v", pt, want, got[k])
+			}
+		}
+	}
+}
+
+// TestMultEvaluatesOnce checks that nested products evaluate each factor once rather than
+// doubling the work at every level.
+func TestMultEvaluatesOnce(t *testing.T) {
+	calls := 0
+	var f Func = GoFunc(func(x []float64) float64 { calls++; return 2 })
+	for i := 0; i < 10; i++ {
+		f = Mult{Constant(1), f}
+	}
+	if got := f.Val(nil); got != 2 || calls != 1 {
+		t.Errorf("got value %v with %v leaf evaluations, want 2 with 1", got, calls)
+	}
+}
+
+func benchmarkEval(b *testing.B, batch bool) {
+	s := prob2d(Config{Width: 5, Depth: 2})
+	s.Net.RandomizeWeights(rand.New(rand.NewSource(1)))
+	pts := s.EvalGrid(0)
+	u := s.Fields[0].Func
+	for i := 0; i < b.N; i++ {
+		if batch {
+			s.Net.EvalBatch(u, pts)
+			continue
+		}
+		for _, pt := range pts {
+			s.Net.EvalFunc(u, pt)
+		}
+	}
+}
+
+func BenchmarkEvalFunc(b *testing.B)  { benchmarkEval(b, false) }
+func BenchmarkEvalBatch(b *testing.B) { benchmarkEval(b, true) }
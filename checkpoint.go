@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+
+	"gonum.org/v1/gonum/optimize"
+)
+
+type weightsFile struct {
+	Weights []float64          `json:"weights"`
+	Params  map[string]float64 `json:"params,omitempty"`
+}
+
+// SaveWeights writes the network's current trainable values (weights and raw param values) to
+// the named file as JSON.
+func (n *Network) SaveWeights(path string) error {
+	data, err := json.MarshalIndent(weightsFile{Weights: n.trainableVals(), Params: n.ParamValues()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// LoadWeights sets the network's trainable values from a file written by SaveWeights.  The
+// network must have the same architecture as the one the weights were saved from.
+func (n *Network) LoadWeights(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var wf weightsFile
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+
+	vars := n.trainable()
+	if len(wf.Weights) != len(vars) {
+		return fmt.Errorf("%v: has %v weights, network has %v", path, len(wf.Weights), len(vars))
+	}
+	n.initState()
+	for i, v := range vars {
+		n.state[int(v)] = wf.Weights[i]
+	}
+	return nil
+}
+
+// Checkpoint is a snapshot of training progress written by train when Network.CheckpointPath is
+// set.  Its weights and params use the same format as SaveWeights so it can also be loaded with
+// LoadWeights.
+type Checkpoint struct {
+	Weights     []float64          `json:"weights"`
+	Params      map[string]float64 `json:"params,omitempty"`
+	Iteration   int                `json:"iteration"`
+	LossHistory []float64          `json:"loss_history"`
+	// Optimizer is the BFGS state at the checkpoint.  It is only saved when training with the
+	// default (nil) Method - with other methods resuming restarts the optimizer from the
+	// checkpoint's weights.
+	Optimizer *BFGSState `json:"optimizer,omitempty"`
+}
+
+// BFGSState is the state of the BFGS method at a major iteration: the previous location and
+// gradient and the (row-major) inverse Hessian estimate.
+type BFGSState struct {
+	X       []float64 `json:"x"`
+	Grad    []float64 `json:"grad"`
+	InvHess []float64 `json:"inv_hess"`
+	First   bool      `json:"first"`
+}
+
+// writeCheckpoint writes a checkpoint with the trainable values x and the optimizer state (if
+// any).  The file is replaced atomically so a crash never leaves a partial checkpoint.
+func (n *Network) writeCheckpoint(x []float64, b *checkpointBFGS) error {
+	cp := Checkpoint{Weights: x, Iteration: n.Iterations, LossHistory: n.LossHistory}
+	if b != nil {
+		cp.Optimizer = b.state
+	}
+	if len(n.Params) > 0 {
+		state := append([]float64{}, n.state...)
+		for i, v := range n.trainable() {
+			state[int(v)] = x[i]
+		}
+		cp.Params = map[string]float64{}
+		for _, p := range n.Params {
+			cp.Params[p.Name] = p.Val(state)
+		}
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := n.CheckpointPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, n.CheckpointPath)
+}
+
+// LoadCheckpoint restores the network's weights, iteration count, loss history and optimizer
+// state from a checkpoint so that the next call to Train continues the checkpointed run exactly
+// where it left off.
+func (n *Network) LoadCheckpoint(path string) error {
+	if err := n.LoadWeights(path); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	if s := cp.Optimizer; s != nil {
+		dim := len(cp.Weights)
+		if len(s.X) != dim || len(s.Grad) != dim || len(s.InvHess) != dim*dim {
+			return fmt.Errorf("%v: optimizer state doesn't match the %v weights", path, dim)
+		}
+	}
+	n.Iterations = cp.Iteration
+	n.LossHistory = cp.LossHistory
+	n.resume = cp
+	return nil
+}
+
+// Resume loads a checkpoint and continues training from it.  It returns NonFinite if training
+// fails.
+func (n *Network) Resume(path string) error {
+	if err := n.LoadCheckpoint(path); err != nil {
+		return err
+	}
+	n.Train()
+	if n.NonFinite != nil {
+		return n.NonFinite
+	}
+	return nil
+}
+
+// checkpointBFGS is a BFGS method equivalent to optimize.BFGS (with its default bisection line
+// search) whose state can be saved in checkpoints and restored.  It drives gonum's Method
+// operation protocol directly, so it must be kept in step with the gonum version in use.
+type checkpointBFGS struct {
+	state *BFGSState
+	// resume makes the first direction continue from the restored state instead of restarting.
+	resume bool
+	ls     *optimize.LinesearchMethod
+	status optimize.Status
+	err    error
+}
+
+func newCheckpointBFGS(cp *Checkpoint) *checkpointBFGS {
+	b := &checkpointBFGS{}
+	if cp != nil && cp.Optimizer != nil {
+		b.state, b.resume = cp.Optimizer, true
+	}
+	b.ls = &optimize.LinesearchMethod{NextDirectioner: b, Linesearcher: &optimize.Bisection{}}
+	return b
+}
+
+func (b *checkpointBFGS) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+func (b *checkpointBFGS) Init(dim, tasks int) int {
+	b.status, b.err = optimize.NotTerminated, nil
+	return 1
+}
+
+func (b *checkpointBFGS) Status() (optimize.Status, error) { return b.status, b.err }
+
+func (b *checkpointBFGS) Run(operation chan<- optimize.Task, result <-chan optimize.Task, tasks []optimize.Task) {
+	b.status, b.err = b.run(operation, result, tasks[0])
+	close(operation)
+}
+
+func (b *checkpointBFGS) run(operation chan<- optimize.Task, result <-chan optimize.Task, task optimize.Task) (optimize.Status, error) {
+	// result must be drained until closed before operation is closed
+	defer func() {
+		for range result {
+		}
+	}()
+
+	// evaluate the starting location and announce it as the first major iteration
+	for _, op := range []optimize.Operation{optimize.FuncEvaluation | optimize.GradEvaluation, optimize.MajorIteration} {
+		task.Op = op
+		operation <- task
+		if task = <-result; task.Op == optimize.PostIteration {
+			return optimize.NotTerminated, nil
+		}
+		if f := task.F; math.IsNaN(f) || math.IsInf(f, 1) {
+			task.Op = optimize.MethodDone
+			operation <- task
+			return optimize.Failure, optimize.ErrFunc(f)
+		}
+	}
+
+	op, err := b.ls.Init(task.Location)
+	for err == nil {
+		task.Op = op
+		operation <- task
+		if task = <-result; task.Op == optimize.PostIteration {
+			return optimize.NotTerminated, nil
+		}
+		op, err = b.ls.Iterate(task.Location)
+	}
+	task.Op = optimize.MethodDone
+	operation <- task
+	return optimize.Failure, err
+}
+
+func (b *checkpointBFGS) InitDirection(loc *optimize.Location, dir []float64) float64 {
+	if b.resume {
+		b.resume = false
+		return b.NextDirection(loc, dir)
+	}
+	dim := len(loc.X)
+	b.state = &BFGSState{
+		X:       append([]float64{}, loc.X...),
+		Grad:    append([]float64{}, loc.Gradient...),
+		InvHess: make([]float64, dim*dim),
+		First:   true,
+	}
+	// the initial inverse Hessian is the identity so the direction is the steepest descent
+	norm := 0.0
+	for i, g := range loc.Gradient {
+		dir[i] = -g
+		norm += g * g
+	}
+	return 1 / math.Sqrt(norm)
+}
+
+func (b *checkpointBFGS) NextDirection(loc *optimize.Location, dir []float64) float64 {
+	st := b.state
+	dim := len(loc.X)
+	s := make([]float64, dim)
+	y := make([]float64, dim)
+	sDotY, yDotY := 0.0, 0.0
+	for i := range s {
+		s[i] = loc.X[i] - st.X[i]
+		y[i] = loc.Gradient[i] - st.Grad[i]
+		sDotY += s[i] * y[i]
+		yDotY += y[i] * y[i]
+	}
+	h := st.InvHess
+
+	if st.First {
+		// rescale the initial inverse Hessian (Nocedal & Wright, eq. 6.20)
+		for i := range h {
+			h[i] = 0
+		}
+		for i := 0; i < dim; i++ {
+			h[i*dim+i] = sDotY / yDotY
+		}
+		st.First = false
+	}
+
+	if sDotY != 0 {
+		// H += (s'y + y'Hy)/(s'y)^2 * ss' - (Hys' + sy'H)/(s'y)
+		hy := make([]float64, dim)
+		yHy := 0.0
+		for i := 0; i < dim; i++ {
+			for j := 0; j < dim; j++ {
+				hy[i] += h[i*dim+j] * y[j]
+			}
+			yHy += y[i] * hy[i]
+		}
+		scale := (1 + yHy/sDotY) / sDotY
+		for i := 0; i < dim; i++ {
+			for j := 0; j < dim; j++ {
+				h[i*dim+j] += scale*s[i]*s[j] - (hy[i]*s[j]+s[i]*hy[j])/sDotY
+			}
+		}
+	}
+
+	copy(st.X, loc.X)
+	copy(st.Grad, loc.Gradient)
+	for i := 0; i < dim; i++ {
+		dir[i] = 0
+		for j := 0; j < dim; j++ {
+			dir[i] -= h[i*dim+j] * loc.Gradient[j]
+		}
+	}
+	return 1
+}
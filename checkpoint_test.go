@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestWarmStart(t *testing.T) {
+	s := registry["1d"].Build(Config{Resolution: 4})
+	s.Net.Train()
+	iters, want := s.Net.Iterations, s.Net.trainableVals()
+	if iters == 0 {
+		t.Fatalf("first run took no iterations")
+	}
+
+	// the second run starts from the already converged weights so there is nothing left to do
+	s.Net.Train()
+	if s.Net.Iterations != iters {
+		t.Errorf("warm started run took %v iterations, want 0", s.Net.Iterations-iters)
+	}
+	for i, w := range s.Net.trainableVals() {
+		if w != want[i] {
+			t.Errorf("weight %v changed from %v to %v", i, want[i], w)
+		}
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	build := func() *Network {
+		net := registry["1d"].Build(Config{Resolution: 4, Width: 3, Depth: 1}).Net
+		net.RandomizeWeights(rand.New(rand.NewSource(3)))
+		return net
+	}
+
+	// uninterrupted run
+	full := build()
+	full.MaxIterations = 8
+	full.CheckpointPath, full.CheckpointEvery = filepath.Join(dir, "full.json"), 4
+	full.Train()
+	if full.Iterations != 8 {
+		t.Fatalf("got %v iterations, want 8", full.Iterations)
+	}
+
+	// interrupted after 4 iterations and resumed in a new process (i.e. a fresh network)
+	part := build()
+	part.MaxIterations = 4
+	part.CheckpointPath = filepath.Join(dir, "part.json")
+	part.Train()
+
+	resumed := build()
+	resumed.MaxIterations = 8
+	if err := resumed.Resume(part.CheckpointPath); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Iterations != 8 || len(resumed.LossHistory) != len(full.LossHistory) {
+		t.Fatalf("got %v iterations and %v losses, want 8 and %v", resumed.Iterations, len(resumed.LossHistory), len(full.LossHistory))
+	}
+	for i := range full.LossHistory {
+		if full.LossHistory[i] != resumed.LossHistory[i] {
+			t.Errorf("iteration %v: resumed loss %v != uninterrupted loss %v", i, resumed.LossHistory[i], full.LossHistory[i])
+		}
+	}
+	want, got := full.trainableVals(), resumed.trainableVals()
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("weight %v: resumed %v != uninterrupted %v", i, got[i], want[i])
+		}
+	}
+
+	// the final checkpoint can be loaded as plain weights too
+	plain := build()
+	if err := plain.LoadWeights(full.CheckpointPath); err != nil {
+		t.Fatal(err)
+	}
+	if plain.trainableVals()[0] != want[0] {
+		t.Errorf("checkpoint weights don't match the trained weights")
+	}
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/optimize"
+)
+
+const usage = `usage: adiff <command> [flags] <problem>
+
+commands:
+    list     list the available problems
+    solve    train a problem's network and write its solution
+    eval     evaluate a previously solved problem's solution on a grid
+    plot     plot a previously solved problem's solution
+    check    build a problem and sanity check its cost function
+    study    sweep network sizes, training points and optimizers for a problem
+
+<problem> is either the name of a built-in problem or the path to a .yaml, .yml
+or .json problem definition file.
+
+Run 'adiff <command> -h' for a command's flags.
+`
+
+// cliFlags holds the flags shared by the problem subcommands.
+type cliFlags struct {
+	fs        *flag.FlagSet
+	cfg       Config
+	optimizer string
+	seed      int64
+	weights   string
+	verbose   bool
+	evalRes   int
+	out       string
+	plot      string
+	field     string
+
+	refine, refineAdd int
+	maxIter           int
+	checkpoint        string
+	checkpointEvery   int
+	resume            string
+	valFrac           float64
+	patience          int
+	l1, l2, gradPen   float64
+	checkFinite       bool
+
+	// study flags
+	widths, depths, resolutions intList
+	optimizerList               string
+	xaxis                       string
+}
+
+// intList is a comma separated list of integers flag.
+type intList []int
+
+func (l *intList) String() string {
+	var strs []string
+	for _, v := range *l {
+		strs = append(strs, strconv.Itoa(v))
+	}
+	return strings.Join(strs, ",")
+}
+
+func (l *intList) Set(s string) error {
+	*l = nil
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return err
+		}
+		*l = append(*l, v)
+	}
+	return nil
+}
+
+func newCLIFlags(cmd string) *cliFlags {
+	f := &cliFlags{fs: flag.NewFlagSet(cmd, flag.ContinueOnError)}
+	fs := f.fs
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: adiff %v [flags] <problem>\n", cmd)
+		fs.PrintDefaults()
+	}
+	if cmd != "study" {
+		fs.IntVar(&f.cfg.Resolution, "res", 0, "number of training points along each input dimension (0 for problem default)")
+		fs.IntVar(&f.cfg.Width, "width", 0, "neurons per hidden layer (0 for problem default)")
+		fs.IntVar(&f.cfg.Depth, "depth", 0, "number of hidden layers (0 for problem default)")
+		fs.StringVar(&f.weights, "weights", "", "trained weights file (default '<problem>.weights.json')")
+	}
+	switch cmd {
+	case "solve":
+		fs.StringVar(&f.optimizer, "optimizer", "", "optimization method: bfgs, lbfgs, cg, gd or nelder-mead (default the problem's choice or bfgs)")
+		fs.Int64Var(&f.seed, "seed", 0, "seed for random initial weights (0 initializes all weights to 1)")
+		fs.BoolVar(&f.verbose, "v", false, "print every training point as it is evaluated")
+		fs.StringVar(&f.out, "out", "", "file to write the solution to (.csv, .tsv, .npy or .npz) - stdout if empty")
+		fs.StringVar(&f.plot, "plot", "", "file to write a plot of the solution to (.svg or .png)")
+		fs.IntVar(&f.evalRes, "evalres", 0, "number of evaluation points along each input dimension (0 for problem default)")
+		fs.IntVar(&f.refine, "refine", 0, "number of adaptive refinements adding training points where the residual is largest")
+		fs.IntVar(&f.refineAdd, "refine-add", 5, "training points added per refinement (candidates are the evaluation grid)")
+		fs.IntVar(&f.maxIter, "maxiter", 0, "maximum total number of optimizer iterations (0 for no limit)")
+		fs.StringVar(&f.checkpoint, "checkpoint", "", "file to periodically write training checkpoints to")
+		fs.IntVar(&f.checkpointEvery, "checkpoint-every", 10, "optimizer iterations between checkpoints")
+		fs.StringVar(&f.resume, "resume", "", "checkpoint file to resume training from")
+		fs.Float64Var(&f.valFrac, "val", 0, "fraction of the training points held out for validation")
+		fs.IntVar(&f.patience, "patience", 0, "stop after this many iterations without validation loss improvement (0 never stops early)")
+		fs.Float64Var(&f.l1, "l1", 0, "weight of an L1 penalty on the network weights")
+		fs.Float64Var(&f.l2, "l2", 0, "weight of an L2 penalty on the network weights")
+		fs.BoolVar(&f.checkFinite, "check-finite", false, "stop training and report where the first NaN or Inf in the cost appears")
+		fs.Float64Var(&f.gradPen, "gradpen", 0, "weight of a squared gradient penalty on every field")
+	case "eval":
+		fs.StringVar(&f.out, "out", "", "file to write the solution to (.csv, .tsv, .npy or .npz) - stdout if empty")
+		fs.IntVar(&f.evalRes, "evalres", 0, "number of evaluation points along each input dimension (0 for problem default)")
+	case "plot":
+		fs.StringVar(&f.out, "out", "", "file to write the plot to (.svg or .png)")
+		fs.StringVar(&f.field, "field", "", "solution field to plot for 2D problems (default the first field)")
+		fs.IntVar(&f.evalRes, "evalres", 0, "number of evaluation points along each input dimension (0 for problem default)")
+	case "study":
+		fs.Var(&f.widths, "widths", "comma separated neurons per hidden layer to sweep (default problem default)")
+		fs.Var(&f.depths, "depths", "comma separated numbers of hidden layers to sweep (default problem default)")
+		fs.Var(&f.resolutions, "resolutions", "comma separated training point resolutions to sweep (default problem default)")
+		fs.StringVar(&f.optimizerList, "optimizers", "", "comma separated optimization methods to sweep (default problem default)")
+		fs.IntVar(&f.evalRes, "evalres", 0, "number of evaluation points along each input dimension for error norms (0 for problem default)")
+		fs.StringVar(&f.out, "out", "", "file to write the results table to (.csv, .tsv, .npy or .npz) - stdout if empty")
+		fs.StringVar(&f.plot, "plot", "", "file to write a log-log plot of the errors to (.svg or .png)")
+		fs.StringVar(&f.xaxis, "x", "weights", "x-axis of the plot: weights or points")
+	}
+	return f
+}
+
+// parse parses the flags in args and returns the selected problem - either a registered problem
+// name or the path to a problem definition file (see ProblemSpec).
+func (f *cliFlags) parse(args []string) (Case, error) {
+	if err := f.fs.Parse(args); err != nil {
+		return Case{}, err
+	}
+	if f.fs.NArg() != 1 {
+		f.fs.Usage()
+		return Case{}, fmt.Errorf("expected exactly one problem name")
+	}
+	var c Case
+	switch strings.ToLower(filepath.Ext(f.fs.Arg(0))) {
+	case ".json", ".yaml", ".yml":
+		var err error
+		if c, err = LoadProblem(f.fs.Arg(0)); err != nil {
+			return Case{}, err
+		}
+	default:
+		var ok bool
+		if c, ok = registry[f.fs.Arg(0)]; !ok {
+			return Case{}, fmt.Errorf("unknown problem '%v' (see 'adiff list')", f.fs.Arg(0))
+		}
+	}
+	if f.weights == "" {
+		f.weights = c.Name + ".weights.json"
+	}
+	return c, nil
+}
+
+func runCLI(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("no command given")
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		return cmdList(os.Stdout)
+	case "solve", "eval", "plot", "check", "study":
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command '%v'", cmd)
+	}
+
+	f := newCLIFlags(cmd)
+	c, err := f.parse(args)
+	if err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if cmd == "study" {
+		return cmdStudy(c, f)
+	}
+
+	setup := c.Build(f.cfg)
+	switch cmd {
+	case "solve":
+		return cmdSolve(setup, f)
+	case "eval":
+		return cmdEval(setup, f)
+	case "plot":
+		return cmdPlot(setup, f)
+	}
+	return cmdCheck(setup, os.Stdout)
+}
+
+func cmdStudy(c Case, f *cliFlags) error {
+	st := &Study{
+		Case:        c,
+		Widths:      f.widths,
+		Depths:      f.depths,
+		Resolutions: f.resolutions,
+		EvalRes:     f.evalRes,
+		Log:         os.Stderr,
+	}
+	if f.optimizerList != "" {
+		st.Optimizers = strings.Split(f.optimizerList, ",")
+	}
+	if f.plot != "" {
+		// check the axis before spending time on training
+		if _, err := StudyPlot(nil, f.xaxis); err != nil {
+			return err
+		}
+	}
+
+	results, err := st.Run()
+	if err != nil {
+		return err
+	}
+	t := StudyTable(results)
+	if f.out == "" {
+		err = writeDelimited(os.Stdout, t, '\t')
+	} else {
+		err = WriteTable(f.out, t)
+	}
+	if err != nil || f.plot == "" {
+		return err
+	}
+	p, err := StudyPlot(results, f.xaxis)
+	if err != nil {
+		return err
+	}
+	return p.Save(f.plot, 640, 480)
+}
+
+func cmdList(w io.Writer) error {
+	for _, c := range Cases() {
+		fmt.Fprintf(w, "%-12v %v\n", c.Name, c.Desc)
+	}
+	return nil
+}
+
+// optimizers maps command line names to optimization methods.
+var optimizers = map[string]func() optimize.Method{
+	"bfgs":        func() optimize.Method { return &optimize.BFGS{} },
+	"lbfgs":       func() optimize.Method { return &optimize.LBFGS{} },
+	"cg":          func() optimize.Method { return &optimize.CG{} },
+	"gd":          func() optimize.Method { return &optimize.GradientDescent{} },
+	"nelder-mead": func() optimize.Method { return &optimize.NelderMead{} },
+}
+
+func cmdSolve(s *Setup, f *cliFlags) error {
+	net := s.Net
+	if f.optimizer != "" {
+		newMethod, ok := optimizers[f.optimizer]
+		if !ok {
+			return fmt.Errorf("unknown optimizer '%v'", f.optimizer)
+		}
+		net.Method = newMethod()
+	}
+	net.Verbose, net.CheckFinite = f.verbose, f.checkFinite
+	if f.seed != 0 {
+		net.RandomizeWeights(rand.New(rand.NewSource(f.seed)))
+	}
+
+	if f.l1 > 0 {
+		net.AddL1(f.l1)
+	}
+	if f.l2 > 0 {
+		net.AddL2(f.l2)
+	}
+	if f.gradPen > 0 {
+		for _, u := range s.Fields {
+			net.AddGradientPenalty(f.gradPen, u, s.DomainVars()...).Name = "grad-" + u.Name
+		}
+	}
+	if f.valFrac > 0 {
+		v, err := net.SplitValidation(f.valFrac, rand.New(rand.NewSource(f.seed)))
+		if err != nil {
+			return err
+		}
+		v.Patience, v.Out = f.patience, os.Stdout
+	}
+	net.MaxIterations = f.maxIter
+	net.CheckpointPath, net.CheckpointEvery = f.checkpoint, f.checkpointEvery
+	if f.resume != "" {
+		if err := net.LoadCheckpoint(f.resume); err != nil {
+			return err
+		}
+	}
+	net.Train()
+	if net.NonFinite != nil {
+		return net.NonFinite
+	}
+	r := &Refiner{Candidates: s.EvalGrid(f.evalRes), Add: f.refineAdd, Out: os.Stdout}
+	for i := 0; i < f.refine; i++ {
+		if len(net.Refine(r)) == 0 {
+			break
+		}
+		net.Train()
+		if net.NonFinite != nil {
+			return net.NonFinite
+		}
+	}
+	net.ReportRegularizers(os.Stdout)
+	if s.Report != nil {
+		s.Report()
+	}
+	if err := writeErrors(os.Stdout, s, f); err != nil {
+		return err
+	}
+
+	if err := net.SaveWeights(f.weights); err != nil {
+		return err
+	}
+	if err := writeSolution(s, f); err != nil {
+		return err
+	}
+	if f.plot != "" {
+		f.out = f.plot
+		return cmdPlot(s, f)
+	}
+	return nil
+}
+
+// loadWeights loads the weights trained by a previous solve command.
+func loadWeights(s *Setup, f *cliFlags) error {
+	if err := s.Net.LoadWeights(f.weights); err != nil {
+		return fmt.Errorf("%v (run 'adiff solve' for the problem first)", err)
+	}
+	return nil
+}
+
+func cmdEval(s *Setup, f *cliFlags) error {
+	if err := loadWeights(s, f); err != nil {
+		return err
+	}
+	if err := writeErrors(os.Stderr, s, f); err != nil {
+		return err
+	}
+	return writeSolution(s, f)
+}
+
+// writeErrors writes the solution's error norms for problems with known exact solutions.
+func writeErrors(w io.Writer, s *Setup, f *cliFlags) error {
+	if len(s.Exact) == 0 {
+		return nil
+	}
+	return WriteErrorNorms(w, s.Errors(f.evalRes))
+}
+
+func writeSolution(s *Setup, f *cliFlags) error {
+	t := s.EvalTable(s.EvalGrid(f.evalRes))
+	if f.out == "" {
+		return writeDelimited(os.Stdout, t, '\t')
+	}
+	return WriteTable(f.out, t)
+}
+
+func cmdPlot(s *Setup, f *cliFlags) error {
+	if f.out == "" {
+		return fmt.Errorf("no plot output file given (use -out)")
+	} else if len(s.Fields) == 0 {
+		return fmt.Errorf("problem has no fields to plot")
+	}
+	if len(s.Net.state) == 0 {
+		if err := loadWeights(s, f); err != nil {
+			return err
+		}
+	}
+
+	// plot against the inputs that actually vary over the domain - i.e. skip dummy inputs
+	var axes []Variable
+	for i, v := range s.Net.Vars {
+		if s.Lo[i] != s.Hi[i] {
+			axes = append(axes, v)
+		}
+	}
+
+	res := orDefault(f.evalRes, s.EvalRes)
+	var p *Plot
+	switch len(axes) {
+	case 1:
+		pts := s.EvalGrid(res)
+		p = &Plot{}
+		for _, field := range s.Fields {
+			lp := s.Net.LinePlot(field, axes[0], pts)
+			lp.Lines[0].Name = field.Name
+			p.Lines = append(p.Lines, lp.Lines...)
+			p.XLabel = lp.XLabel
+		}
+	case 2:
+		field := s.Fields[0]
+		if f.field != "" {
+			field.Func = nil
+			for _, sf := range s.Fields {
+				if sf.Name == f.field {
+					field = sf
+				}
+			}
+			if field.Func == nil {
+				return fmt.Errorf("unknown field '%v'", f.field)
+			}
+		}
+		xi, yi := s.Net.varIndex(axes[0]), s.Net.varIndex(axes[1])
+		xs := Grid(s.Lo[xi:xi+1], s.Hi[xi:xi+1], res)
+		ys := Grid(s.Lo[yi:yi+1], s.Hi[yi:yi+1], res)
+		p = s.Net.HeatmapPlot(field, axes[0], axes[1], s.Lo, flatten(xs), flatten(ys))
+		p.Title = field.Name
+	default:
+		return fmt.Errorf("can only plot 1D and 2D problems, got %v dimensions", len(axes))
+	}
+	return p.Save(f.out, 640, 480)
+}
+
+func flatten(pts [][]float64) []float64 {
+	var vals []float64
+	for _, pt := range pts {
+		vals = append(vals, pt...)
+	}
+	return vals
+}
+
+// cmdCheck prints a summary of the problem's setup and verifies that its cost can be evaluated
+// at the initial weights.
+func cmdCheck(s *Setup, w io.Writer) error {
+	net := s.Net
+	var names []string
+	for _, f := range s.Fields {
+		names = append(names, f.Name)
+	}
+	fmt.Fprintf(w, "inputs:          %v\n", len(net.Vars))
+	fmt.Fprintf(w, "weights:         %v\n", len(net.Weights))
+	fmt.Fprintf(w, "params:          %v\n", len(net.Params))
+	fmt.Fprintf(w, "training points: %v\n", len(net.TrainData))
+	fmt.Fprintf(w, "fields:          %v\n", strings.Join(names, " "))
+
+	if net.CostFunc == nil {
+		return fmt.Errorf("problem has no cost function")
+	}
+	for i, pt := range net.TrainData {
+		if len(pt) != len(net.Vars) {
+			return fmt.Errorf("training point %v has %v values, want %v", i+1, len(pt), len(net.Vars))
+		}
+	}
+	cost := net.Cost(net.trainableVals())
+	fmt.Fprintf(w, "initial cost:    %v\n", cost)
+	if math.IsNaN(cost) || math.IsInf(cost, 0) {
+		return fmt.Errorf("initial cost is not finite")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCasesCheck(t *testing.T) {
+	for _, c := range Cases() {
+		var buf bytes.Buffer
+		s := c.Build(Config{Resolution: 1})
+		if err := cmdCheck(s, &buf); err != nil {
+			t.Errorf("problem %v: %v\n%v", c.Name, err, buf.String())
+		}
+		if len(s.Lo) != len(s.Net.Vars) || len(s.Hi) != len(s.Net.Vars) {
+			t.Errorf("problem %v: domain bounds don't match the %v inputs", c.Name, len(s.Net.Vars))
+		}
+	}
+}
+
+func TestCLISolve(t *testing.T) {
+	dir := t.TempDir()
+	weights := filepath.Join(dir, "w.json")
+	sol := filepath.Join(dir, "sol.csv")
+	plot := filepath.Join(dir, "plot.svg")
+
+	// eval without a prior solve should fail
+	if err := runCLI([]string{"eval", "-weights", weights, "1d"}); err == nil {
+		t.Errorf("want error evaluating an unsolved problem, got nil")
+	}
+
+	cmds := [][]string{
+		{"solve", "-res", "5", "-seed", "1", "-l2", "1e-6", "-check-finite", "-refine", "1", "-refine-add", "2", "-weights", weights, "-out", sol, "1d"},
+		{"eval", "-weights", weights, "-evalres", "4", "-out", sol, "1d"},
+		{"plot", "-weights", weights, "-out", plot, "1d"},
+	}
+	for _, args := range cmds {
+		if err := runCLI(args); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+
+	tbl, err := ReadTable(sol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tbl.Rows) != 4 || tbl.Col("x") != 0 || tbl.Col("u") != 2 {
+		t.Errorf("want 4 rows with columns [x dummy u], got %v rows with columns %v", len(tbl.Rows), tbl.Columns)
+	}
+	if data, err := ioutil.ReadFile(plot); err != nil || len(data) == 0 {
+		t.Errorf("want plot written to %v, got err=%v", plot, err)
+	}
+
+	for _, args := range [][]string{{"bogus"}, {"solve", "nope"}, {"solve", "-optimizer", "nope", "-weights", weights, "1d"}} {
+		if err := runCLI(args); err == nil {
+			t.Errorf("%v: want error, got nil", args)
+		}
+	}
+}
+
+func TestCLIResume(t *testing.T) {
+	dir := t.TempDir()
+	weights, cp, sol := filepath.Join(dir, "w.json"), filepath.Join(dir, "cp.json"), filepath.Join(dir, "sol.csv")
+	cmds := [][]string{
+		{"solve", "-res", "4", "-maxiter", "2", "-checkpoint", cp, "-weights", weights, "-out", sol, "1d"},
+		{"solve", "-res", "4", "-resume", cp, "-checkpoint", cp, "-weights", weights, "-out", sol, "1d"},
+	}
+	for _, args := range cmds {
+		if err := runCLI(args); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	s := registry["1d"].Build(Config{Resolution: 4})
+	if err := s.Net.LoadCheckpoint(cp); err != nil {
+		t.Fatal(err)
+	}
+	if s.Net.Iterations <= 2 {
+		t.Errorf("resumed run didn't continue past the checkpoint: %v iterations", s.Net.Iterations)
+	}
+}
+
+func TestCLIPlotNoFields(t *testing.T) {
+	s := &Setup{Net: &Network{}}
+	f := &cliFlags{out: filepath.Join(t.TempDir(), "plot.svg")}
+	if err := cmdPlot(s, f); err == nil {
+		t.Errorf("want error plotting a problem without fields, got nil")
+	}
+}
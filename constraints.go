@@ -0,0 +1,36 @@
+package main
+
+// HardDirichlet transforms the raw network output n into g + d*n which satisfies Dirichlet
+// boundary conditions exactly.  g must match the Dirichlet data on the boundary and d must
+// vanish on the boundary (and be nonzero in the interior).  Use the result in place of n when
+// building residuals - no boundary penalty terms are needed.
+func HardDirichlet(n, g, d Func) Func { return Sum{g, Mult{d, n}} }
+
+// BoxDistance returns a function that is zero on every face of the box lo[i] <= vars[i] <= hi[i]
+// and positive inside it: the product of (v-lo)*(hi-v) over all vars.
+func BoxDistance(lo, hi []float64, vars ...Variable) Func {
+	if len(lo) != len(vars) || len(hi) != len(vars) {
+		panic("BoxDistance: mismatched dimensions")
+	}
+	var d Mult
+	for i, v := range vars {
+		d = append(d, Sum{v, Constant(-lo[i])}, Sum{Constant(hi[i]), Negative(v)})
+	}
+	return d
+}
+
+// LinearLift returns the linear function of v that equals u0 at v == v0 and u1 at v == v1.  It
+// is a convenient g for HardDirichlet on 1D intervals.
+func LinearLift(v Variable, v0, v1, u0, u1 float64) Func {
+	slope := (u1 - u0) / (v1 - v0)
+	return Sum{Constant(u0), Mult{Constant(slope), Sum{v, Constant(-v0)}}}
+}
+
+// EvalFunc evaluates f (usually built from the network's outputs e.g. with HardDirichlet) at the
+// given input variable values and the network's current weights.
+func (n *Network) EvalFunc(f Func, inputVars []float64) float64 {
+	for i, index := range n.Vars {
+		n.state[int(index)] = inputVars[i]
+	}
+	return f.Val(n.state)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHardDirichlet(t *testing.T) {
+	var xv, yv Variable = 0, 1
+	// an arbitrary stand-in for the raw network output
+	raw := Sum{&Tanh{Mult{xv, yv}}, Constant(3)}
+
+	lo, hi := []float64{0, -1}, []float64{2, 1}
+	u := HardDirichlet(raw, LinearLift(xv, 0, 2, 5, 7), BoxDistance(lo, hi, xv, yv))
+
+	tests := []struct {
+		X    []float64
+		Want float64
+	}{
+		{[]float64{0, 0.3}, 5},
+		{[]float64{2, 0.3}, 7},
+		{[]float64{1, -1}, 6},
+		{[]float64{0.5, 1}, 5.5},
+	}
+	for _, test := range tests {
+		if got := u.Val(test.X); math.Abs(got-test.Want) > 1e-10 {
+			t.Errorf("u%v: want %v, got %v", test.X, test.Want, got)
+		}
+	}
+
+	// interior points should still depend on the raw output
+	pt := []float64{1, 0.5}
+	d := (pt[0] - lo[0]) * (hi[0] - pt[0]) * (pt[1] - lo[1]) * (hi[1] - pt[1])
+	want := 6 + d*raw.Val(pt)
+	if got := u.Val(pt); math.Abs(got-want) > 1e-10 {
+		t.Errorf("u%v: want %v, got %v", pt, want, got)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// NewTarget creates a new variable that holds the observed (target) value of some quantity at
+// each training point.  Target values are taken from the network's Targets.
+func (n *Network) NewTarget() Variable {
+	v := Variable(n.nextVarIndex)
+	n.TargetVars = append(n.TargetVars, v)
+	n.nextVarIndex++
+	return v
+}
+
+// AddSample appends a training point with the given input variable values and observed target
+// values (in TargetVars order).  target may be nil for points without observations (e.g. pure
+// collocation points) and individual missing observations can be NaN.
+func (n *Network) AddSample(x, target []float64) {
+	n.TrainData = append(n.TrainData, x)
+	for len(n.Targets) < len(n.TrainData)-1 {
+		n.Targets = append(n.Targets, nil)
+	}
+	n.Targets = append(n.Targets, target)
+}
+
+// setPoint sets the input and target variables in the network state to the values for the
+// i'th training point.
+func (n *Network) setPoint(i int) { n.setPointFrom(n.TrainData, n.Targets, i) }
+
+// setPointFrom is like setPoint for the i'th point of an arbitrary set of points and targets.
+func (n *Network) setPointFrom(pts, targets [][]float64, i int) {
+	for j, index := range n.Vars {
+		n.state[int(index)] = pts[i][j]
+	}
+	for j, index := range n.TargetVars {
+		n.state[int(index)] = math.NaN()
+		if i < len(targets) && j < len(targets[i]) {
+			n.state[int(index)] = targets[i][j]
+		}
+	}
+}
+
+// Misfit converts the difference between a prediction and its observed value into a
+// (nonnegative) loss.
+type Misfit func(diff Func) Func
+
+// MSE is the squared error misfit.  Summed over the training data by Cost, it is the mean
+// squared error scaled by the number of observations.
+func MSE(diff Func) Func { return &Pow{diff, Constant(2)} }
+
+// Huber returns the Huber misfit which is quadratic for errors smaller than delta and linear for
+// larger errors - making it less sensitive to outliers in measured data than MSE.
+func Huber(delta float64) Misfit {
+	return func(diff Func) Func {
+		return Branch(func(x []float64) Func {
+			if math.Abs(diff.Val(x)) <= delta {
+				return Mult{Constant(0.5), &Pow{diff, Constant(2)}}
+			}
+			return Mult{Constant(delta), Sum{Abs(diff), Constant(-0.5 * delta)}}
+		})
+	}
+}
+
+// DataMisfit returns the misfit between the prediction u and the observed target.  It is zero at
+// training points that have no observation for target.
+func DataMisfit(u Func, target Variable, m Misfit) Func {
+	misfit := m(Sum{u, Negative(target)})
+	return Branch(func(x []float64) Func {
+		if math.IsNaN(x[int(target)]) {
+			return Constant(0)
+		}
+		return misfit
+	})
+}
+
+// ReadSamples reads whitespace or comma separated sample rows from r with the same rules as
+// text tables (see ReadTable).  The delimiter is detected from the first non-comment line.  The
+// first ninputs columns of each row are input variable values and the remaining columns are
+// target values.  Empty, "nan" or "NA" target entries are treated as missing observations.
+func ReadSamples(r io.Reader, ninputs int) (inputs, targets [][]float64, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := readDelimited(bytes.NewReader(data), sniffDelim(data))
+	if err != nil {
+		return nil, nil, err
+	} else if err := t.checkNumeric(); err != nil {
+		return nil, nil, err
+	}
+
+	for i, row := range t.Rows {
+		if len(row) < ninputs {
+			return nil, nil, fmt.Errorf("row %v: want at least %v input columns, got %v", i+1, ninputs, len(row))
+		}
+		for _, val := range row[:ninputs] {
+			if math.IsNaN(val) {
+				return nil, nil, fmt.Errorf("row %v: missing input value", i+1)
+			}
+		}
+		inputs = append(inputs, row[:ninputs])
+		var target []float64
+		if len(row) > ninputs {
+			target = row[ninputs:]
+		}
+		targets = append(targets, target)
+	}
+	return inputs, targets, nil
+}
+
+// sniffDelim returns ',' if the first non-comment line of data is comma separated and 0
+// (whitespace) otherwise.
+func sniffDelim(data []byte) rune {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		} else if strings.ContainsRune(line, ',') {
+			return ','
+		}
+		return 0
+	}
+	return 0
+}
+
+// LoadSamples reads samples with ReadSamples and adds them to the network's training data.
+func (n *Network) LoadSamples(r io.Reader) error {
+	inputs, targets, err := ReadSamples(r, len(n.Vars))
+	if err != nil {
+		return err
+	}
+	for i := range inputs {
+		n.AddSample(inputs[i], targets[i])
+	}
+	return nil
+}
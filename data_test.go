@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadSamples(t *testing.T) {
+	src := `# x y target
+0 1 2.5
+1	2 nan
+3 4
+`
+	inputs, targets, err := ReadSamples(strings.NewReader(src), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 3 || len(targets) != 3 {
+		t.Fatalf("want 3 samples, got %v inputs and %v targets", len(inputs), len(targets))
+	}
+	if inputs[1][0] != 1 || inputs[1][1] != 2 {
+		t.Errorf("sample 2: want inputs [1 2], got %v", inputs[1])
+	}
+	if targets[0][0] != 2.5 {
+		t.Errorf("sample 1: want target 2.5, got %v", targets[0])
+	}
+	if !math.IsNaN(targets[1][0]) {
+		t.Errorf("sample 2: want missing (NaN) target, got %v", targets[1])
+	}
+	if targets[2] != nil {
+		t.Errorf("sample 3: want no targets, got %v", targets[2])
+	}
+
+	if _, _, err := ReadSamples(strings.NewReader("0 1\n1 foo\n"), 1); err == nil {
+		t.Errorf("want error for malformed target, got nil")
+	}
+
+	inputs, targets, err = ReadSamples(strings.NewReader("x,y,u\n1,2,NA\n3,4,5\n"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 || inputs[1][1] != 4 || !math.IsNaN(targets[0][0]) || targets[1][0] != 5 {
+		t.Errorf("csv with header: got inputs %v and targets %v", inputs, targets)
+	}
+
+	// a pandas style header with an empty index column name and commas only in comments
+	src = "# written by pandas, see notes\n,x,u\n0,1,2\n1,3,4\n"
+	inputs, targets, err = ReadSamples(strings.NewReader(src), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 || inputs[1][1] != 3 || targets[1][0] != 4 {
+		t.Errorf("pandas csv: got inputs %v and targets %v", inputs, targets)
+	}
+	src = "# x, y, u\n0 1 2\n"
+	if inputs, _, err = ReadSamples(strings.NewReader(src), 2); err != nil || len(inputs) != 1 {
+		t.Errorf("whitespace with commas in comments: got inputs %v, err %v", inputs, err)
+	}
+}
+
+func TestDataMisfit(t *testing.T) {
+	var u, target Variable = 0, 1
+	tests := []struct {
+		Name   string
+		Misfit Misfit
+		X      []float64
+		Want   float64
+	}{
+		{"MSE", MSE, []float64{3, 1}, 4},
+		{"MSE missing", MSE, []float64{3, math.NaN()}, 0},
+		{"Huber small", Huber(1), []float64{1.5, 1}, 0.125},
+		{"Huber large", Huber(1), []float64{4, 1}, 2.5},
+		{"Huber large negative", Huber(1), []float64{-2, 1}, 2.5},
+	}
+	for _, test := range tests {
+		if got := DataMisfit(u, target, test.Misfit).Val(test.X); math.Abs(got-test.Want) > 1e-10 {
+			t.Errorf("%v: want %v, got %v", test.Name, test.Want, got)
+		}
+	}
+}
+
+func TestRegression(t *testing.T) {
+	var net Network
+	in1, _ := net.NewInput()
+	dummyin, _ := net.NewInput()
+	u := net.NewOutput().PullFrom(in1, dummyin)
+	target := net.NewTarget()
+	net.CostFunc = DataMisfit(u, target, MSE)
+
+	for xv := -1.0; xv <= 1; xv += .25 {
+		net.AddSample([]float64{xv, 1}, []float64{0.5 + math.Tanh(xv)})
+	}
+	// a collocation point without observations shouldn't contribute to the misfit
+	net.AddSample([]float64{5, 1}, nil)
+
+	net.Train()
+
+	if cost := net.Cost(net.trainableVals()); cost > 1e-6 {
+		t.Errorf("want converged misfit, got %v", cost)
+	}
+}
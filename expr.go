@@ -0,0 +1,392 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseExpr parses an infix expression such as "k*laplace(u, x) + sin(pi*x)" into a Func.
+// Identifiers are resolved using syms; pi and e are predefined.  Supported operators are
+// + - * / ^ and parentheses.  Supported functions are sin, cos, tanh, exp, ln (or log), sqrt,
+// abs, d(f, v1, v2, ...) (the partial derivative of f w.r.t. v1 then v2, etc.) and
+// laplace(f, v1, v2, ...).  Derivative variables must resolve to a Variable.
+func ParseExpr(src string, syms map[string]Func) (Func, error) {
+	p, err := newExprParser(src, syms)
+	if err != nil {
+		return nil, err
+	}
+	f, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected '%v'", p.peek())
+	}
+	return f, nil
+}
+
+// ParsePredicate parses a boolean condition such as "x == 0 || x >= 1" into a func that reports
+// whether the condition holds at a point.  Comparisons (== != < <= > >=) between expressions (as
+// in ParseExpr) can be combined with &&, || and ! and grouped with parentheses.
+func ParsePredicate(src string, syms map[string]Func) (func(x []float64) bool, error) {
+	p, err := newExprParser(src, syms)
+	if err != nil {
+		return nil, err
+	}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected '%v'", p.peek())
+	}
+	return pred, nil
+}
+
+type exprParser struct {
+	src  string
+	toks []string
+	pos  int
+	syms map[string]Func
+}
+
+func newExprParser(src string, syms map[string]Func) (*exprParser, error) {
+	p := &exprParser{src: src, syms: syms}
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			// exponent
+			if j < len(rs) && (rs[j] == 'e' || rs[j] == 'E') {
+				k := j + 1
+				if k < len(rs) && (rs[k] == '+' || rs[k] == '-') {
+					k++
+				}
+				if k < len(rs) && unicode.IsDigit(rs[k]) {
+					for j = k; j < len(rs) && unicode.IsDigit(rs[j]); j++ {
+					}
+				}
+			}
+			p.toks = append(p.toks, string(rs[i:j]))
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			p.toks = append(p.toks, string(rs[i:j]))
+			i = j
+		default:
+			if i+1 < len(rs) {
+				two := string(rs[i : i+2])
+				switch two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					p.toks = append(p.toks, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/^(),<>!", r) {
+				return nil, fmt.Errorf("expression '%v': invalid character '%c'", src, r)
+			}
+			p.toks = append(p.toks, string(r))
+			i++
+		}
+	}
+	return p, nil
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("expression '%v': %v", p.src, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) done() bool { return p.pos >= len(p.toks) }
+
+func (p *exprParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *exprParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *exprParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			got = "end of expression"
+		}
+		return p.errorf("expected '%v', got '%v'", tok, got)
+	}
+	return nil
+}
+
+func (p *exprParser) or() (func([]float64) bool, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(x []float64) bool { return l(x) || right(x) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) and() (func([]float64) bool, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(x []float64) bool { return l(x) && right(x) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) not() (func([]float64) bool, error) {
+	if p.peek() == "!" {
+		p.next()
+		inner, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(x []float64) bool { return !inner(x) }, nil
+	}
+
+	// a parenthesized predicate - backtrack if it turns out to be an arithmetic expression
+	if p.peek() == "(" {
+		start := p.pos
+		p.next()
+		if pred, err := p.or(); err == nil && p.peek() == ")" {
+			p.next()
+			return pred, nil
+		}
+		p.pos = start
+	}
+	return p.compare()
+}
+
+func (p *exprParser) compare() (func([]float64) bool, error) {
+	left, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	op := p.next()
+	var cmp func(a, b float64) bool
+	switch op {
+	case "==":
+		cmp = func(a, b float64) bool { return a == b }
+	case "!=":
+		cmp = func(a, b float64) bool { return a != b }
+	case "<":
+		cmp = func(a, b float64) bool { return a < b }
+	case "<=":
+		cmp = func(a, b float64) bool { return a <= b }
+	case ">":
+		cmp = func(a, b float64) bool { return a > b }
+	case ">=":
+		cmp = func(a, b float64) bool { return a >= b }
+	default:
+		if op == "" {
+			op = "end of expression"
+		}
+		return nil, p.errorf("expected comparison operator, got '%v'", op)
+	}
+	right, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return func(x []float64) bool { return cmp(left.Val(x), right.Val(x)) }, nil
+}
+
+func (p *exprParser) expr() (Func, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.next()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if op == "-" {
+			right = Negative(right)
+		}
+		left = Sum{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) term() (Func, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "*" || p.peek() == "/" {
+		op := p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "/" {
+			right = Inverse(right)
+		}
+		left = Mult{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) unary() (Func, error) {
+	if p.peek() == "-" {
+		p.next()
+		f, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Negative(f), nil
+	} else if p.peek() == "+" {
+		p.next()
+		return p.unary()
+	}
+	return p.power()
+}
+
+func (p *exprParser) power() (Func, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == "^" {
+		p.next()
+		// right associative: a^b^c == a^(b^c)
+		exp, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Pow{base, exp}, nil
+	}
+	return base, nil
+}
+
+func (p *exprParser) primary() (Func, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, p.errorf("unexpected end of expression")
+	case tok == "(":
+		f, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	case unicode.IsDigit([]rune(tok)[0]) || tok[0] == '.':
+		val, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%v'", tok)
+		}
+		return Constant(val), nil
+	case unicode.IsLetter([]rune(tok)[0]) || tok[0] == '_':
+		if p.peek() == "(" {
+			return p.call(tok)
+		}
+		if f, ok := p.syms[tok]; ok {
+			return f, nil
+		}
+		switch tok {
+		case "pi":
+			return Constant(math.Pi), nil
+		case "e":
+			return Constant(math.E), nil
+		}
+		return nil, p.errorf("unknown identifier '%v'", tok)
+	}
+	return nil, p.errorf("unexpected '%v'", tok)
+}
+
+func (p *exprParser) call(name string) (Func, error) {
+	p.next() // "("
+	var args []Func
+	var argNames []string
+	for p.peek() != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		argNames = append(argNames, p.peek())
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next() // ")"
+
+	unary := map[string]func(Func) Func{
+		"sin":  func(f Func) Func { return Sin{f} },
+		"cos":  func(f Func) Func { return Cos{f} },
+		"tanh": func(f Func) Func { return &Tanh{f} },
+		"exp":  Exp,
+		"ln":   func(f Func) Func { return Ln{f} },
+		"log":  func(f Func) Func { return Ln{f} },
+		"sqrt": func(f Func) Func { return &Pow{f, Constant(0.5)} },
+		"abs":  Abs,
+	}
+	if fn, ok := unary[name]; ok {
+		if len(args) != 1 {
+			return nil, p.errorf("%v takes 1 argument, got %v", name, len(args))
+		}
+		return fn(args[0]), nil
+	}
+
+	switch name {
+	case "d", "laplace":
+		if len(args) < 2 {
+			return nil, p.errorf("%v needs a function and at least one variable", name)
+		}
+		var vars []Variable
+		for i, arg := range args[1:] {
+			v, ok := arg.(Variable)
+			if !ok {
+				return nil, p.errorf("%v: '%v' is not an input variable", name, argNames[i+1])
+			}
+			vars = append(vars, v)
+		}
+		if name == "laplace" {
+			return Laplace(args[0], vars...), nil
+		}
+		f := args[0]
+		for _, v := range vars {
+			f = f.Partial(v)
+		}
+		return f, nil
+	}
+	return nil, p.errorf("unknown function '%v'", name)
+}
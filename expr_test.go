@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	x, y := Variable(0), Variable(1)
+	syms := map[string]Func{"x": x, "y": y, "k": Constant(2)}
+	pt := []float64{0.5, 3}
+
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2*3", 7},
+		{"(1 + 2)*3", 9},
+		{"2^3^2", 512},
+		{"-2^2", -4},
+		{"8/4/2", 1},
+		{"1.5e1 - x", 14.5},
+		{"k*x*y", 3},
+		{"sin(pi*x) + cos(0)", 2},
+		{"exp(ln(y)) + sqrt(4) + abs(-1) + tanh(0)", 6},
+		{"d(x^2*y, x)", 3},
+		{"d(x^2*y^3, x, y)", 27},
+		{"laplace(x^2 + y^3, x, y)", 20},
+		{"e", math.E},
+	}
+	for _, test := range tests {
+		f, err := ParseExpr(test.src, syms)
+		if err != nil {
+			t.Errorf("%v: %v", test.src, err)
+			continue
+		}
+		if got := f.Val(pt); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%v: got %v, want %v", test.src, got, test.want)
+		}
+	}
+
+	for _, src := range []string{"", "1 +", "(x", "x y", "foo", "bar(x)", "sin(x, y)", "d(x, k)", "d(x)", "x # 2"} {
+		if _, err := ParseExpr(src, syms); err == nil {
+			t.Errorf("%q: want error, got nil", src)
+		}
+	}
+}
+
+func TestParsePredicate(t *testing.T) {
+	syms := map[string]Func{"x": Variable(0), "t": Variable(1)}
+	tests := []struct {
+		src  string
+		pt   []float64
+		want bool
+	}{
+		{"x == 0 || x == 1", []float64{1, 0}, true},
+		{"x == 0 || x == 1", []float64{0.5, 0}, false},
+		{"t == 0 && x > 0", []float64{0.5, 0}, true},
+		{"!(t == 0) && x >= 0.5", []float64{0.5, 0}, false},
+		{"(x + 1)*2 <= 3", []float64{0.5, 0}, true},
+		{"(x < 1 || t < 1) && x != t", []float64{2, 0}, true},
+	}
+	for _, test := range tests {
+		pred, err := ParsePredicate(test.src, syms)
+		if err != nil {
+			t.Errorf("%v: %v", test.src, err)
+			continue
+		}
+		if got := pred(test.pt); got != test.want {
+			t.Errorf("%v at %v: got %v, want %v", test.src, test.pt, got, test.want)
+		}
+	}
+
+	for _, src := range []string{"x", "x = 0", "x == 0 ||", "(x == 0"} {
+		if _, err := ParsePredicate(src, syms); err == nil {
+			t.Errorf("%q: want error, got nil", src)
+		}
+	}
+}
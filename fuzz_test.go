@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var genConstants = []float64{0, 1, -1, 2, 0.5, -3, 1.7}
+
+// genBranch is a Branch choosing Pos where Cond >= 0 and Neg elsewhere.  It keeps the branch's
+// subexpressions (which a Branch closure hides) for shrinking.
+type genBranch struct {
+	Branch
+	Cond, Pos, Neg Func
+}
+
+func newGenBranch(cond, pos, neg Func) genBranch {
+	return genBranch{Branch(func(x []float64) Func {
+		if cond.Val(x) >= 0 {
+			return pos
+		}
+		return neg
+	}), cond, pos, neg}
+}
+
+// genGoFunc is a GoFunc evaluating Func.  It keeps Func (which the GoFunc closure hides) for
+// shrinking.
+type genGoFunc struct {
+	GoFunc
+	Func Func
+}
+
+func newGenGoFunc(f Func) genGoFunc { return genGoFunc{GoFunc(f.Val), f} }
+
+// genParam returns a parameter backed by variable v - bounded to (-1, 3) if bounded is true.
+func genParam(v Variable, bounded bool) *Param {
+	p := &Param{Name: "p" + v.String(), Var: v, Init: 1}
+	if bounded {
+		p.SetBounds(-1, 3)
+	}
+	return p
+}
+
+// genFunc returns a random expression of at most the given depth over nvars variables.
+func genFunc(rng *rand.Rand, depth, nvars int) Func {
+	if depth <= 0 || rng.Intn(4) == 0 {
+		switch rng.Intn(5) {
+		case 0, 1:
+			return Variable(rng.Intn(nvars))
+		case 2:
+			return genParam(Variable(rng.Intn(nvars)), rng.Intn(2) == 0)
+		}
+		return Constant(genConstants[rng.Intn(len(genConstants))])
+	}
+	sub := func() Func { return genFunc(rng, depth-1, nvars) }
+	switch rng.Intn(16) {
+	case 13:
+		// integrate over the first variable leaving the others free
+		return NewIntegral(sub(), []Variable{0}, []float64{-1}, []float64{1}, 3)
+	case 14:
+		return newGenGoFunc(sub())
+	case 15:
+		if rng.Intn(2) == 0 {
+			return Normalize(sub(), 0, 5)
+		}
+		return Denormalize(sub(), -3, 1)
+	case 11:
+		return newGenBranch(sub(), sub(), sub())
+	case 12:
+		return NamedFunc{"f", sub()}
+	case 0:
+		return Sum{sub(), sub(), sub()}[:2+rng.Intn(2)]
+	case 1:
+		return Mult{sub(), sub(), sub()}[:2+rng.Intn(2)]
+	case 2:
+		return Ln{sub()}
+	case 3:
+		// mostly constant exponents, like the equations built by problems
+		if rng.Intn(3) == 0 {
+			return &Pow{sub(), sub()}
+		}
+		return &Pow{sub(), Constant(genConstants[rng.Intn(len(genConstants))])}
+	case 4:
+		return Absolute{sub()}
+	case 5:
+		return &Tanh{sub()}
+	case 6:
+		return &Passthrough{sub()}
+	case 7:
+		return Sin{sub()}
+	case 8:
+		return Cos{sub()}
+	case 9:
+		return Exp(sub())
+	default:
+		return Negative(sub())
+	}
+}
+
+// children returns the subexpressions of the node types produced by genFunc.
+func children(f Func) []Func {
+	switch f := f.(type) {
+	case Sum:
+		return f
+	case Mult:
+		return f
+	case Ln:
+		return []Func{f.Func}
+	case *Pow:
+		return []Func{f.Base, f.Exponent}
+	case Absolute:
+		return []Func{f.Func}
+	case *Tanh:
+		return []Func{f.Func}
+	case *Passthrough:
+		return []Func{f.Func}
+	case Sin:
+		return []Func{f.Func}
+	case Cos:
+		return []Func{f.Func}
+	case genBranch:
+		return []Func{f.Cond, f.Pos, f.Neg}
+	case NamedFunc:
+		return []Func{f.Func}
+	case *Integral:
+		return []Func{f.Func}
+	case genGoFunc:
+		return []Func{f.Func}
+	}
+	return nil
+}
+
+// withChildren returns a copy of f with its subexpressions replaced by kids.
+func withChildren(f Func, kids []Func) Func {
+	switch f := f.(type) {
+	case Sum:
+		return Sum(kids)
+	case Mult:
+		return Mult(kids)
+	case Ln:
+		return Ln{kids[0]}
+	case *Pow:
+		return &Pow{kids[0], kids[1]}
+	case Absolute:
+		return Absolute{kids[0]}
+	case *Tanh:
+		return &Tanh{kids[0]}
+	case *Passthrough:
+		return &Passthrough{kids[0]}
+	case Sin:
+		return Sin{kids[0]}
+	case Cos:
+		return Cos{kids[0]}
+	case genBranch:
+		return newGenBranch(kids[0], kids[1], kids[2])
+	case NamedFunc:
+		return NamedFunc{"f", kids[0]}
+	case *Integral:
+		return &Integral{kids[0], f.Vars, f.Points, f.Weights}
+	case genGoFunc:
+		return newGenGoFunc(kids[0])
+	}
+	return f
+}
+
+// shrinkCandidates returns expressions strictly simpler than f: its subexpressions, f with a
+// Sum or Mult term dropped, simpler leaves and f with one subexpression shrunk.
+func shrinkCandidates(f Func) []Func {
+	switch f := f.(type) {
+	case Constant:
+		if f != 0 && f != 1 {
+			return []Func{Constant(0), Constant(1)}
+		} else if f == 1 {
+			return []Func{Constant(0)}
+		}
+		return nil
+	case Variable:
+		if f != 0 {
+			return []Func{Constant(0), Variable(0)}
+		}
+		return []Func{Constant(0)}
+	case *Param:
+		return []Func{Constant(0), f.Var}
+	}
+
+	kids := children(f)
+	cands := append([]Func{}, kids...)
+	switch f.(type) {
+	case Sum, Mult:
+		for i := range kids {
+			dropped := append(append([]Func{}, kids[:i]...), kids[i+1:]...)
+			cands = append(cands, withChildren(f, dropped))
+		}
+	}
+	for i, k := range kids {
+		for _, c := range shrinkCandidates(k) {
+			shrunk := append([]Func{}, kids...)
+			shrunk[i] = c
+			cands = append(cands, withChildren(f, shrunk))
+		}
+	}
+	return cands
+}
+
+// shrink greedily simplifies f as long as the result still fails.
+func shrink(f Func, fails func(Func) bool) Func {
+	for {
+		improved := false
+		for _, c := range shrinkCandidates(f) {
+			if fails(c) {
+				f, improved = c, true
+				break
+			}
+		}
+		if !improved {
+			return f
+		}
+	}
+}
+
+// maxMagnitude returns the largest magnitude of f and its subexpressions at x ignoring NaNs (e.g.
+// in branches that aren't taken).  Integrands are checked at the integral's quadrature points.
+func maxMagnitude(f Func, x []float64) float64 {
+	m := math.Abs(f.Val(x))
+	if in, ok := f.(*Integral); ok {
+		for _, s := range in.states(x) {
+			if v := maxMagnitude(in.Func, s); v > m {
+				m = v
+			}
+		}
+		return m
+	}
+	for _, k := range children(f) {
+		if v := maxMagnitude(k, x); v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// checkFunc checks that f's String doesn't panic, that Simplify preserves f's value and that
+// Partial agrees with finite differences at each point where f is finite and smooth.  Points
+// where any subexpression is large are skipped because rounding errors there (e.g. in sin(1e8 +
+// x)) swamp the finite differences.
+func checkFunc(f Func, pts [][]float64) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	_ = f.String()
+
+	simple := f.Simplify()
+	for _, pt := range pts {
+		val := f.Val(pt)
+		if math.IsNaN(val) || maxMagnitude(f, pt) > 1e6 {
+			continue
+		}
+		if got := simple.Val(pt); !(math.Abs(got-val) <= 1e-9*math.Max(1, math.Abs(val))) {
+			return fmt.Errorf("Simplify changed value at %v: %v -> %v (simplified %v)", pt, val, got, simple)
+		}
+		for i := range pt {
+			want, errEst := richardson(f.Val, pt, i)
+			if math.IsNaN(want) || math.Abs(want) > 1e6 || errEst > 1e-4*math.Max(1, math.Abs(want)) {
+				// not smooth near this point (e.g. a kink or singularity)
+				continue
+			}
+			got := f.Partial(Variable(i)).Val(pt)
+			if !gradClose(got, want, errEst) {
+				return fmt.Errorf("d/d%v at %v: got %v, finite difference %v", Variable(i), pt, got, want)
+			}
+		}
+	}
+	return nil
+}
+
+func fuzzFunc(t *testing.T, seed int64, depth int) {
+	const nvars = 2
+	rng := rand.New(rand.NewSource(seed))
+	f := genFunc(rng, depth, nvars)
+	var pts [][]float64
+	for i := 0; i < 5; i++ {
+		pts = append(pts, []float64{4*rng.Float64() - 2, 4*rng.Float64() - 2})
+	}
+
+	if err := checkFunc(f, pts); err != nil {
+		min := shrink(f, func(g Func) bool { return checkFunc(g, pts) != nil })
+		t.Errorf("seed %v: %v\n    expression: %v\n    minimal: %v\n    %v", seed, err, f, min, checkFunc(min, pts))
+	}
+}
+
+func TestRandomFuncs(t *testing.T) {
+	for seed := int64(1); seed <= 300; seed++ {
+		fuzzFunc(t, seed, 4)
+	}
+	// sin(((v1^2)^-3) + (v0 + 1)) used to fail from rounding in its huge argument
+	fuzzFunc(t, -932, 5)
+}
+
+func FuzzFunc(f *testing.F) {
+	for _, seed := range []int64{1, 2, 3, 42} {
+		f.Add(seed, uint8(3))
+	}
+	f.Fuzz(func(t *testing.T, seed int64, depth uint8) {
+		fuzzFunc(t, seed, int(depth%6))
+	})
+}
+
+func TestShrink(t *testing.T) {
+	// a predicate that fails whenever the expression contains a Ln node
+	var hasLn func(Func) bool
+	hasLn = func(f Func) bool {
+		if _, ok := f.(Ln); ok {
+			return true
+		}
+		for _, k := range children(f) {
+			if hasLn(k) {
+				return true
+			}
+		}
+		return false
+	}
+	f := Sum{Mult{Constant(2), Sin{Variable(1)}}, &Pow{Ln{Mult{Variable(1), Constant(3)}}, Constant(2)}}
+	if got := shrink(f, hasLn); got.String() != (Ln{Constant(0)}).String() {
+		t.Errorf("want ln(0), got %v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GradCheckTol is the relative tolerance used by CheckGradient and Network.CheckCostGradient
+// on top of the estimated finite difference error.
+var GradCheckTol = 1e-6
+
+// GradientMismatch is a partial derivative that disagrees with its finite difference estimate.
+type GradientMismatch struct {
+	Var Variable
+	// Got is the analytic derivative and Want the finite difference estimate.
+	Got, Want float64
+}
+
+// GradientError lists every partial derivative that failed a gradient check.
+type GradientError []GradientMismatch
+
+func (e GradientError) Error() string {
+	var msgs []string
+	for _, m := range e {
+		msgs = append(msgs, fmt.Sprintf("d/d%v: got %v, finite difference %v", m.Var, m.Got, m.Want))
+	}
+	return "gradient check failed: " + strings.Join(msgs, "; ")
+}
+
+// CheckGradient compares f.Partial w.r.t. each of vars at x against central finite differences
+// improved with Richardson extrapolation.  It returns a GradientError listing the mismatches or
+// nil if every partial derivative agrees.
+func CheckGradient(f Func, x []float64, vars []Variable) error {
+	var mismatches GradientError
+	for _, v := range vars {
+		got := f.Partial(v).Val(x)
+		want, errEst := richardson(f.Val, x, int(v))
+		if !gradClose(got, want, errEst) {
+			mismatches = append(mismatches, GradientMismatch{v, got, want})
+		}
+	}
+	if len(mismatches) > 0 {
+		return mismatches
+	}
+	return nil
+}
+
+// CheckCostGradient compares CostGradient against finite differences of Cost at the given
+// trainable values (see CheckGradient).  Mismatches are reported for the corresponding
+// trainable variables.
+func (n *Network) CheckCostGradient(weights []float64) error {
+	n.initState()
+	vars := n.trainable()
+	grad := make([]float64, len(vars))
+	n.CostGradient(grad, weights)
+
+	var mismatches GradientError
+	for i, v := range vars {
+		want, errEst := richardson(n.Cost, weights, i)
+		if !gradClose(grad[i], want, errEst) {
+			mismatches = append(mismatches, GradientMismatch{v, grad[i], want})
+		}
+	}
+	// leave the network state at the given weights
+	n.Cost(weights)
+	if len(mismatches) > 0 {
+		return mismatches
+	}
+	return nil
+}
+
+// richardson estimates the derivative of f w.r.t. x[i] by combining central differences with
+// steps h and h/2 which cancels the O(h^2) error term.  The difference between the extrapolated
+// and the finer central difference is returned as a (conservative) error estimate.
+func richardson(f func([]float64) float64, x []float64, i int) (deriv, errEst float64) {
+	xv := append([]float64{}, x...)
+	central := func(h float64) float64 {
+		xv[i] = x[i] + h
+		fwd := f(xv)
+		xv[i] = x[i] - h
+		bwd := f(xv)
+		xv[i] = x[i]
+		return (fwd - bwd) / (2 * h)
+	}
+	h := 1e-3 * math.Max(1, math.Abs(x[i]))
+	coarse, fine := central(h), central(h/2)
+	deriv = (4*fine - coarse) / 3
+	return deriv, math.Abs(deriv - fine)
+}
+
+func gradClose(got, want, errEst float64) bool {
+	return math.Abs(got-want) <= GradCheckTol*math.Max(1, math.Abs(want))+errEst
+}
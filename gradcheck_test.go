@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// badPartial wraps a Func with a deliberately wrong derivative.
+type badPartial struct{ Func }
+
+func (b badPartial) Partial(v Variable) Func { return Mult{Constant(1.01), b.Func.Partial(v)} }
+
+func TestCheckGradient(t *testing.T) {
+	pt := []float64{0.3, 0.7}
+	for i, prob := range problems {
+		vars := []Variable{x, y}[:prob.Nvars]
+		if err := CheckGradient(prob.Eqn, pt[:prob.Nvars], vars); err != nil {
+			t.Errorf("problem %v (%v): %v", i+1, prob.Eqn, err)
+		}
+	}
+
+	f := Sum{Mult{Sin{x}, Exp(y)}, &Tanh{Mult{x, y}}}
+	if err := CheckGradient(f, pt, []Variable{x, y}); err != nil {
+		t.Errorf("%v: %v", f, err)
+	}
+
+	err := CheckGradient(badPartial{f}, pt, []Variable{x, y})
+	if gerr, ok := err.(GradientError); !ok || len(gerr) != 2 || gerr[0].Var != x || gerr[1].Var != y {
+		t.Errorf("want mismatches for both variables, got %v", err)
+	}
+}
+
+func TestCheckCostGradient(t *testing.T) {
+	// fit du/dx = k with an unknown parameter k and a data point
+	var net Network
+	in, xv := net.NewInput()
+	hidden := net.NewLayers([]*Neuron{in}, 2, 1)
+	u := net.NewField("u").PullFrom(hidden...)
+	k := net.NewParam("k", 0.5)
+	target := net.NewTarget()
+	net.CostFunc = Sum{&Pow{Sum{u.Partial(xv), Negative(k)}, Constant(2)}, DataMisfit(u, target, MSE)}
+	net.AddSample([]float64{0.2}, []float64{1})
+	net.AddSample([]float64{0.8}, nil)
+	net.RandomizeWeights(rand.New(rand.NewSource(1)))
+	if err := net.CheckCostGradient(net.trainableVals()); err != nil {
+		t.Error(err)
+	}
+
+	// a wrong cost gradient is caught
+	net.partials[0] = Constant(1e3)
+	if err := net.CheckCostGradient(net.trainableVals()); err == nil {
+		t.Errorf("want gradient error for a corrupted partial, got nil")
+	}
+}
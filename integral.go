@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Integral is the integral of Func w.r.t. Vars approximated with a quadrature rule - the
+// weighted sum of Func at the quadrature Points (values for Vars, with all other variables
+// taken from the evaluation point).  Its partial derivatives w.r.t. other variables (e.g.
+// network weights) are the integrals of Func's partials, so an Integral can be used in a
+// CostFunc - e.g. for energy functionals or conservation constraints like int(u) = c.  A
+// CostFunc is summed over every training point, so wrap whole-domain terms with Network.Once to
+// count them once per cost (or add them with a non per-point Regularizer).
+type Integral struct {
+	Func    Func
+	Vars    []Variable
+	Points  [][]float64
+	Weights []float64
+}
+
+// NewIntegral returns the integral of f over the box lo <= vars <= hi using a tensor product
+// Gauss-Legendre rule with n points along each dimension.
+func NewIntegral(f Func, vars []Variable, lo, hi []float64, n int) *Integral {
+	if len(vars) != len(lo) || len(lo) != len(hi) {
+		panic(fmt.Sprintf("NewIntegral: got %v vars with %v lower and %v upper bounds", len(vars), len(lo), len(hi)))
+	} else if n <= 0 {
+		panic(fmt.Sprintf("NewIntegral: need a positive number of points, got %v", n))
+	}
+	pts, weights := gaussLegendreBox(lo, hi, n)
+	return &Integral{Func: f, Vars: vars, Points: pts, Weights: weights}
+}
+
+// gaussLegendreBox returns the points and weights of the tensor product Gauss-Legendre rule with
+// n points along each dimension of the box lo <= x <= hi.
+func gaussLegendreBox(lo, hi []float64, n int) (pts [][]float64, ws []float64) {
+	nodes, weights := GaussLegendre(n)
+	idx := make([]int, len(lo))
+	for {
+		pt := make([]float64, len(lo))
+		w := 1.0
+		for d, i := range idx {
+			half := (hi[d] - lo[d]) / 2
+			pt[d] = lo[d] + half*(nodes[i]+1)
+			w *= half * weights[i]
+		}
+		pts = append(pts, pt)
+		ws = append(ws, w)
+
+		d := 0
+		for ; d < len(idx); d++ {
+			if idx[d]++; idx[d] < n {
+				break
+			}
+			idx[d] = 0
+		}
+		if d == len(idx) {
+			return pts, ws
+		}
+	}
+}
+
+// NewMonteCarloIntegral returns the integral of f over the part of the box lo <= vars <= hi
+// where inside (if non-nil) is true using n uniformly random samples of the box.  This
+// handles irregular domains where tensor Gauss-Legendre rules don't apply.
+func NewMonteCarloIntegral(f Func, vars []Variable, lo, hi []float64, n int, inside func([]float64) bool, rng *rand.Rand) *Integral {
+	if len(vars) != len(lo) || len(lo) != len(hi) {
+		panic(fmt.Sprintf("NewMonteCarloIntegral: got %v vars with %v lower and %v upper bounds", len(vars), len(lo), len(hi)))
+	} else if n <= 0 {
+		panic(fmt.Sprintf("NewMonteCarloIntegral: need a positive number of samples, got %v", n))
+	}
+	vol := 1.0
+	for d := range lo {
+		vol *= hi[d] - lo[d]
+	}
+	in := &Integral{Func: f, Vars: vars}
+	for i := 0; i < n; i++ {
+		pt := make([]float64, len(vars))
+		for d := range pt {
+			pt[d] = lo[d] + rng.Float64()*(hi[d]-lo[d])
+		}
+		if inside == nil || inside(pt) {
+			in.Points = append(in.Points, pt)
+			in.Weights = append(in.Weights, vol/float64(n))
+		}
+	}
+	return in
+}
+
+// GaussLegendre returns the n nodes (in increasing order) and weights of the Gauss-Legendre
+// quadrature rule on [-1, 1] which integrates polynomials up to degree 2n-1 exactly.
+func GaussLegendre(n int) (nodes, weights []float64) {
+	if n <= 0 {
+		panic(fmt.Sprintf("GaussLegendre: need a positive number of points, got %v", n))
+	}
+	nodes, weights = make([]float64, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		// Newton iteration for the i'th root of the Legendre polynomial P_n
+		z := math.Cos(math.Pi * (float64(i) + 0.75) / (float64(n) + 0.5))
+		var deriv float64
+		for iter := 0; iter < 100; iter++ {
+			p1, p2 := 1.0, 0.0
+			for j := 1; j <= n; j++ {
+				p1, p2 = (float64(2*j-1)*z*p1-float64(j-1)*p2)/float64(j), p1
+			}
+			deriv = float64(n) * (z*p1 - p2) / (z*z - 1)
+			dz := p1 / deriv
+			z -= dz
+			if math.Abs(dz) < 1e-15 {
+				break
+			}
+		}
+		nodes[i] = -z
+		weights[i] = 2 / ((1 - z*z) * deriv * deriv)
+	}
+	return nodes, weights
+}
+
+// states returns copies of x with the integration variables set to each quadrature point.
+func (in *Integral) states(x []float64) [][]float64 {
+	X := make([][]float64, len(in.Points))
+	for k, pt := range in.Points {
+		X[k] = append([]float64{}, x...)
+		for d, v := range in.Vars {
+			X[k][int(v)] = pt[d]
+		}
+	}
+	return X
+}
+
+func (in *Integral) Val(x []float64) float64 {
+	vals := make([]float64, len(in.Points))
+	ValBatch(in.Func, in.states(x), vals)
+	tot := 0.0
+	for k, v := range vals {
+		tot += in.Weights[k] * v
+	}
+	return tot
+}
+
+func (in *Integral) Partial(v Variable) Func {
+	for _, iv := range in.Vars {
+		if iv == v {
+			// the integration variables are bound
+			return Constant(0)
+		}
+	}
+	return &Integral{in.Func.Partial(v), in.Vars, in.Points, in.Weights}
+}
+
+func (in *Integral) Simplify() Func {
+	f := in.Func.Simplify()
+	if c, ok := f.(Constant); ok {
+		tot := 0.0
+		for _, w := range in.Weights {
+			tot += w
+		}
+		return Constant(float64(c) * tot)
+	}
+	return &Integral{f, in.Vars, in.Points, in.Weights}
+}
+
+func (in *Integral) String() string { return fmt.Sprintf("integral(%v d%v)", in.Func, in.Vars) }
+
+// Once returns f scaled so that summing it over the training points in Cost (with their
+// PointWeights) counts it once.  This lets terms that don't depend on the training point - e.g.
+// a penalty on an Integral over every input like (int(u) - c)^2 - be part of a CostFunc.
+func (n *Network) Once(f Func) Func { return &once{f, n} }
+
+type once struct {
+	Func Func
+	n    *Network
+}
+
+func (o *once) Val(x []float64) float64 {
+	tot := 0.0
+	for i := range o.n.TrainData {
+		tot += o.n.pointWeight(i)
+	}
+	return o.Func.Val(x) / tot
+}
+
+func (o *once) Partial(v Variable) Func { return &once{o.Func.Partial(v), o.n} }
+
+func (o *once) Simplify() Func {
+	f := o.Func.Simplify()
+	if f == Constant(0) {
+		return f
+	}
+	return &once{f, o.n}
+}
+
+func (o *once) String() string { return fmt.Sprintf("once(%v)", o.Func) }
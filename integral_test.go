@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGaussLegendre(t *testing.T) {
+	for n := 1; n <= 8; n++ {
+		nodes, weights := GaussLegendre(n)
+		// x^k integrates exactly for k <= 2n-1
+		for k := 0; k <= 2*n-1; k++ {
+			got := 0.0
+			for i, z := range nodes {
+				got += weights[i] * math.Pow(z, float64(k))
+			}
+			want := 0.0
+			if k%2 == 0 {
+				want = 2 / float64(k+1)
+			}
+			if math.Abs(got-want) > 1e-13 {
+				t.Errorf("n=%v: integral of x^%v: want %v, got %v", n, k, want, got)
+			}
+		}
+	}
+}
+
+func TestIntegral(t *testing.T) {
+	var a Variable = 2
+	// integral of a*x^2*y over [0,1]x[0,2] = 2a/3
+	f := Mult{a, &Pow{x, Constant(2)}, y}
+	in := NewIntegral(f, []Variable{x, y}, []float64{0, 0}, []float64{1, 2}, 3)
+	state := []float64{0, 0, 1.5}
+	if got := in.Val(state); math.Abs(got-1) > 1e-13 {
+		t.Errorf("integral: want 1, got %v", got)
+	}
+	if got := in.Partial(a).Val(state); math.Abs(got-2.0/3) > 1e-13 {
+		t.Errorf("d/da: want 2/3, got %v", got)
+	}
+	if got := in.Partial(x).Simplify(); got != Constant(0) {
+		t.Errorf("d/dx of an integral over x: want 0, got %v", got)
+	}
+	if got := NewIntegral(Constant(3), []Variable{x}, []float64{1}, []float64{2}, 2).Simplify(); math.Abs(got.Val(nil)-3) > 1e-13 {
+		t.Errorf("integral of 3 over [1,2]: want 3, got %v", got)
+	}
+
+	// the area of the unit disk
+	disk := func(pt []float64) bool { return pt[0]*pt[0]+pt[1]*pt[1] <= 1 }
+	mc := NewMonteCarloIntegral(Constant(1), []Variable{x, y}, []float64{-1, -1}, []float64{1, 1}, 20000, disk, rand.New(rand.NewSource(1)))
+	if got := mc.Val([]float64{0, 0}); math.Abs(got-math.Pi) > 0.05 {
+		t.Errorf("disk area: want %v, got %v", math.Pi, got)
+	}
+}
+
+func TestIntegralConstraint(t *testing.T) {
+	var net Network
+	in, xv := net.NewInput()
+	hidden := net.NewLayers([]*Neuron{in}, 2, 1)
+	u := net.NewField("u").PullFrom(hidden...)
+	net.CostFunc = Constant(0)
+	net.TrainData = [][]float64{{0}}
+	integral := NewIntegral(u, []Variable{xv}, []float64{0}, []float64{1}, 4)
+	net.AddRegularizer("mass", &Pow{Sum{integral, Constant(-0.7)}, Constant(2)}, 1, false)
+	net.RandomizeWeights(rand.New(rand.NewSource(1)))
+	if err := net.CheckCostGradient(net.trainableVals()); err != nil {
+		t.Fatal(err)
+	}
+
+	// train a single neuron to satisfy int(u dx) = 0.7 over [0,1]
+	net = Network{}
+	in, xv = net.NewInput()
+	u = net.NewField("u").PullFrom(in)
+	net.CostFunc = Constant(0)
+	net.TrainData = [][]float64{{0}}
+	integral = NewIntegral(u, []Variable{xv}, []float64{0}, []float64{1}, 6)
+	net.AddRegularizer("mass", &Pow{Sum{integral, Constant(-0.7)}, Constant(2)}, 1, false)
+	net.Train()
+	if got := net.EvalFunc(integral, []float64{0}); math.Abs(got-0.7) > 1e-6 {
+		t.Errorf("constrained integral: want 0.7, got %v", got)
+	}
+}
+
+func TestIntegralInCost(t *testing.T) {
+	var net Network
+	in, xv := net.NewInput()
+	u := net.NewField("u").PullFrom(in)
+	net.TrainData = [][]float64{{0}, {0.5}, {1}}
+	integral := NewIntegral(u, []Variable{xv}, []float64{0}, []float64{1}, 6)
+	mass := &Pow{Sum{integral, Constant(-0.7)}, Constant(2)}
+	net.CostFunc = Sum{u, net.Once(mass)}
+	net.RandomizeWeights(rand.New(rand.NewSource(1)))
+
+	// the mass penalty counts once however many (weighted) points there are
+	weights := []float64{1, 1, 1}
+	for _, w := range [][]float64{nil, {0.25, 0.5, 0.25}} {
+		net.PointWeights = w
+		if w != nil {
+			weights = w
+		}
+		want := net.EvalFunc(mass, []float64{0})
+		for i, pt := range net.TrainData {
+			want += weights[i] * net.EvalFunc(u, pt)
+		}
+		if got := net.Cost(net.trainableVals()); math.Abs(got-want) > 1e-12 {
+			t.Errorf("point weights %v: want cost %v, got %v", w, want, got)
+		}
+		if err := net.CheckCostGradient(net.trainableVals()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// train a single neuron to satisfy int(u dx) = 0.7 over [0,1] with the penalty in CostFunc
+	net = Network{}
+	in, xv = net.NewInput()
+	u = net.NewField("u").PullFrom(in)
+	net.TrainData = [][]float64{{0}, {0.5}, {1}}
+	integral = NewIntegral(u, []Variable{xv}, []float64{0}, []float64{1}, 6)
+	net.CostFunc = net.Once(&Pow{Sum{integral, Constant(-0.7)}, Constant(2)})
+	net.Train()
+	if got := net.EvalFunc(integral, []float64{0}); math.Abs(got-0.7) > 1e-6 {
+		t.Errorf("constrained integral: want 0.7, got %v", got)
+	}
+}
+
+func TestIntegralArgs(t *testing.T) {
+	tests := []struct {
+		Name string
+		Fn   func()
+	}{
+		{"no points", func() { NewIntegral(x, []Variable{x}, []float64{0}, []float64{1}, 0) }},
+		{"missing bound", func() { NewIntegral(x, []Variable{x, y}, []float64{0}, []float64{1, 2}, 2) }},
+		{"no samples", func() { NewMonteCarloIntegral(x, []Variable{x}, []float64{0}, []float64{1}, -1, nil, nil) }},
+		{"no nodes", func() { GaussLegendre(0) }},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: want panic, got none", test.Name)
+				}
+			}()
+			test.Fn()
+		}()
+	}
+}
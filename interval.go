@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Interval is the closed interval [Lo, Hi].  An interval with NaN bounds is empty - e.g. the
+// logarithm of a negative interval.
+type Interval struct {
+	Lo, Hi float64
+}
+
+var realLine = Interval{math.Inf(-1), math.Inf(1)}
+
+func (iv Interval) String() string { return fmt.Sprintf("[%v, %v]", iv.Lo, iv.Hi) }
+
+// Contains returns true if v lies within the interval.
+func (iv Interval) Contains(v float64) bool { return iv.Lo <= v && v <= iv.Hi }
+
+func (iv Interval) empty() bool { return math.IsNaN(iv.Lo) || math.IsNaN(iv.Hi) }
+
+// union returns the smallest interval enclosing both a and b.
+func (iv Interval) union(b Interval) Interval {
+	if iv.empty() {
+		return b
+	} else if b.empty() {
+		return iv
+	}
+	return Interval{math.Min(iv.Lo, b.Lo), math.Max(iv.Hi, b.Hi)}
+}
+
+// outward widens the interval by one ulp on each side to cover floating point rounding.
+func outward(iv Interval) Interval {
+	return Interval{math.Nextafter(iv.Lo, math.Inf(-1)), math.Nextafter(iv.Hi, math.Inf(1))}
+}
+
+// hull returns the interval enclosing the given values.
+func hull(vals ...float64) Interval {
+	iv := Interval{vals[0], vals[0]}
+	for _, v := range vals[1:] {
+		iv.Lo, iv.Hi = math.Min(iv.Lo, v), math.Max(iv.Hi, v)
+	}
+	return iv
+}
+
+// mul multiplies interval bounds treating 0*Inf as 0.
+func mul(a, b float64) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return a * b
+}
+
+// EvalInterval returns an enclosure of f's values over the box - i.e. every f.Val(x) with
+// x[i] in box[i] lies within the returned interval.  Enclosures are usually wider than f's exact
+// range, especially when a variable appears several times in f.  Nodes without an interval rule
+// (e.g. Branch and GoFunc) enclose the whole real line.
+func EvalInterval(f Func, box []Interval) Interval {
+	switch f := f.(type) {
+	case Constant:
+		return Interval{float64(f), float64(f)}
+	case Variable:
+		return box[int(f)]
+	case Sum:
+		tot := Interval{}
+		for _, term := range f {
+			iv := EvalInterval(term, box)
+			if iv.empty() {
+				return iv
+			}
+			tot = outward(Interval{tot.Lo + iv.Lo, tot.Hi + iv.Hi})
+			if math.IsNaN(tot.Lo) || math.IsNaN(tot.Hi) {
+				// -Inf + Inf
+				return realLine
+			}
+		}
+		return tot
+	case Mult:
+		// Mult.Val is zero wherever any factor is zero - even if other factors are NaN
+		prod, zero := Interval{1, 1}, false
+		for _, factor := range f {
+			iv := EvalInterval(factor, box)
+			if iv.Lo == 0 && iv.Hi == 0 {
+				return iv
+			}
+			zero = zero || iv.Contains(0)
+			if prod.empty() || iv.empty() {
+				prod = Interval{math.NaN(), math.NaN()}
+				continue
+			}
+			prod = outward(hull(mul(prod.Lo, iv.Lo), mul(prod.Lo, iv.Hi), mul(prod.Hi, iv.Lo), mul(prod.Hi, iv.Hi)))
+		}
+		if zero {
+			return prod.union(Interval{0, 0})
+		}
+		return prod
+	case *Pow:
+		return powInterval(EvalInterval(f.Base, box), EvalInterval(f.Exponent, box))
+	case Ln:
+		iv := EvalInterval(f.Func, box)
+		if iv.Hi < 0 {
+			return Interval{math.NaN(), math.NaN()}
+		}
+		return outward(Interval{math.Log(math.Max(iv.Lo, 0)), math.Log(iv.Hi)})
+	case *Tanh:
+		iv := EvalInterval(f.Func, box)
+		return outward(Interval{math.Tanh(iv.Lo), math.Tanh(iv.Hi)})
+	case Absolute:
+		iv := EvalInterval(f.Func, box)
+		if iv.Contains(0) {
+			return Interval{0, math.Max(-iv.Lo, iv.Hi)}
+		}
+		return hull(math.Abs(iv.Lo), math.Abs(iv.Hi))
+	case Sin:
+		return sinInterval(EvalInterval(f.Func, box))
+	case Cos:
+		iv := EvalInterval(f.Func, box)
+		return sinInterval(outward(Interval{iv.Lo + math.Pi/2, iv.Hi + math.Pi/2}))
+	case *Passthrough:
+		return EvalInterval(f.Func, box)
+	case *Neuron:
+		return EvalInterval(f.getFunc(), box)
+	case *Param:
+		return EvalInterval(f.value(), box)
+	case NamedFunc:
+		return EvalInterval(f.Func, box)
+	}
+	return realLine
+}
+
+func powInterval(base, exp Interval) Interval {
+	if exp.Lo == 0 && exp.Hi == 0 || base.Lo == 1 && base.Hi == 1 {
+		// math.Pow(b, 0) and math.Pow(1, e) are 1 even for NaN b and e
+		return Interval{1, 1}
+	} else if base.empty() || exp.empty() {
+		return Interval{math.NaN(), math.NaN()}
+	}
+	if exp.Lo != exp.Hi {
+		if base.Lo <= 0 {
+			return realLine
+		}
+		// b^e = exp(e*ln(b)) is monotone in each argument for positive b
+		return outward(hull(math.Pow(base.Lo, exp.Lo), math.Pow(base.Lo, exp.Hi), math.Pow(base.Hi, exp.Lo), math.Pow(base.Hi, exp.Hi)))
+	}
+
+	c := exp.Lo
+	if c != math.Trunc(c) {
+		// only defined for nonnegative bases where b^c is monotone
+		if base.Hi < 0 {
+			return Interval{math.NaN(), math.NaN()}
+		}
+		base.Lo = math.Max(base.Lo, 0)
+		return outward(hull(math.Pow(base.Lo, c), math.Pow(base.Hi, c)))
+	}
+
+	even := math.Mod(c, 2) == 0
+	if base.Contains(0) {
+		switch {
+		case c > 0 && even:
+			return outward(Interval{0, math.Max(math.Pow(base.Lo, c), math.Pow(base.Hi, c))})
+		case c > 0:
+			return outward(Interval{math.Pow(base.Lo, c), math.Pow(base.Hi, c)})
+		case even:
+			return Interval{0, math.Inf(1)}
+		default:
+			return realLine
+		}
+	}
+	// integer powers are monotone on intervals that exclude zero
+	return outward(hull(math.Pow(base.Lo, c), math.Pow(base.Hi, c)))
+}
+
+func sinInterval(iv Interval) Interval {
+	if iv.Hi-iv.Lo >= 2*math.Pi || math.IsInf(iv.Lo, 0) || math.IsInf(iv.Hi, 0) {
+		return Interval{-1, 1}
+	}
+	out := outward(hull(math.Sin(iv.Lo), math.Sin(iv.Hi)))
+	// include the extremes at pi/2 + k*pi inside the interval
+	for k := math.Ceil((iv.Lo - math.Pi/2) / math.Pi); math.Pi/2+k*math.Pi <= iv.Hi; k++ {
+		if math.Mod(k, 2) == 0 {
+			out.Hi = 1
+		} else {
+			out.Lo = -1
+		}
+	}
+	return Interval{math.Max(out.Lo, -1), math.Min(out.Hi, 1)}
+}
+
+// Bound returns an enclosure of f's values over the box lo <= x <= hi (in n.Vars order) at the
+// network's current weights.  Each input dimension is split into divs (at least 1) cells and
+// the union of the cells' enclosures returned - more cells give tighter bounds.
+func (n *Network) Bound(f Func, lo, hi []float64, divs int) Interval {
+	n.initState()
+	box := make([]Interval, len(n.state))
+	for i, v := range n.state {
+		box[i] = Interval{v, v}
+	}
+	if divs < 1 {
+		divs = 1
+	}
+
+	bound := Interval{math.NaN(), math.NaN()}
+	cell := make([]int, len(n.Vars))
+	for {
+		for i, v := range n.Vars {
+			w := (hi[i] - lo[i]) / float64(divs)
+			box[int(v)] = Interval{lo[i] + float64(cell[i])*w, lo[i] + float64(cell[i]+1)*w}
+			if cell[i] == divs-1 {
+				box[int(v)].Hi = hi[i]
+			}
+		}
+		bound = bound.union(EvalInterval(f, box))
+
+		// advance to the next cell
+		i := 0
+		for ; i < len(cell); i++ {
+			if cell[i]++; cell[i] < divs {
+				break
+			}
+			cell[i] = 0
+		}
+		if i == len(cell) {
+			return bound
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEvalInterval(t *testing.T) {
+	box := []Interval{{-1, 2}, {0.5, 3}}
+	tests := []struct {
+		F    Func
+		Want Interval
+	}{
+		{Sum{x, y, Constant(1)}, Interval{0.5, 6}},
+		{Mult{x, y}, Interval{-3, 6}},
+		{&Pow{x, Constant(2)}, Interval{0, 4}},
+		{&Pow{x, Constant(3)}, Interval{-1, 8}},
+		{&Pow{y, Constant(-1)}, Interval{1.0 / 3, 2}},
+		{&Pow{y, Constant(0.5)}, Interval{math.Sqrt(0.5), math.Sqrt(3)}},
+		{&Pow{x, Constant(-1)}, realLine},
+		{&Pow{Ln{Constant(-1)}, Constant(0)}, Interval{1, 1}},
+		{Exp(x), Interval{math.Exp(-1), math.Exp(2)}},
+		{Ln{y}, Interval{math.Log(0.5), math.Log(3)}},
+		{Ln{x}, Interval{math.Inf(-1), math.Log(2)}},
+		{&Tanh{x}, Interval{math.Tanh(-1), math.Tanh(2)}},
+		{Abs(x), Interval{0, 2}},
+		{Abs(Sum{x, Constant(-3)}), Interval{1, 4}},
+		{Sin{y}, Interval{math.Sin(3), 1}},
+		{Cos{y}, Interval{math.Cos(3), math.Cos(0.5)}},
+		{Cos{Mult{Constant(2), y}}, Interval{-1, math.Cos(6)}},
+		{Branch(func([]float64) Func { return x }), realLine},
+		{Mult{Constant(0), Ln{Sum{y, Constant(-5)}}}, Interval{0, 0}},
+		{Mult{x, Ln{Sum{y, Constant(-5)}}}, Interval{0, 0}},
+		{Sum{Ln{Constant(0)}, Negative(Ln{Constant(0)})}, realLine},
+	}
+	for _, test := range tests {
+		got := EvalInterval(test.F, box)
+		if math.Abs(got.Lo-test.Want.Lo) > 1e-12 || math.Abs(got.Hi-test.Want.Hi) > 1e-12 || got.Lo > test.Want.Lo || got.Hi < test.Want.Hi {
+			t.Errorf("%v over %v: want %v, got %v", test.F, box, test.Want, got)
+		}
+	}
+	if got := EvalInterval(Ln{Sum{x, Constant(-5)}}, box); !got.empty() {
+		t.Errorf("ln of a negative interval: want empty, got %v", got)
+	}
+}
+
+func TestIntervalEnclosure(t *testing.T) {
+	// the values of random expressions at random points in a box must lie in its enclosure
+	for seed := int64(1); seed <= 300; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		f := genFunc(rng, 4, 2)
+		lo := []float64{4*rng.Float64() - 2, 4*rng.Float64() - 2}
+		box := []Interval{{lo[0], lo[0] + rng.Float64()}, {lo[1], lo[1] + rng.Float64()}}
+		iv := EvalInterval(f, box)
+		for i := 0; i < 20; i++ {
+			pt := []float64{box[0].Lo + rng.Float64()*(box[0].Hi-box[0].Lo), box[1].Lo + rng.Float64()*(box[1].Hi-box[1].Lo)}
+			if v := f.Val(pt); !math.IsNaN(v) && !iv.Contains(v) {
+				t.Errorf("seed %v: %v at %v = %v is outside %v", seed, f, pt, v, iv)
+				break
+			}
+		}
+	}
+}
+
+func TestBound(t *testing.T) {
+	var net Network
+	in, _ := net.NewInput()
+	hidden := net.NewLayers([]*Neuron{in}, 3, 1)
+	u := net.NewField("u").PullFrom(hidden...)
+	net.RandomizeWeights(rand.New(rand.NewSource(1)))
+
+	lo, hi := []float64{-1}, []float64{1}
+	coarse, fine := net.Bound(u, lo, hi, 1), net.Bound(u, lo, hi, 16)
+	if fine.Lo < coarse.Lo || fine.Hi > coarse.Hi {
+		t.Errorf("subdivided bound %v is wider than %v", fine, coarse)
+	}
+	for _, pt := range Grid(lo, hi, 101) {
+		if v := net.EvalFunc(u, pt); !fine.Contains(v) {
+			t.Fatalf("u(%v) = %v is outside the bound %v", pt, v, fine)
+		}
+	}
+}
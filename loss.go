@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// LossTerm is a single named contribution to a Loss (e.g. the interior residual, a boundary
+// condition or a data misfit).  Func is the term's (already squared/nonnegative) contribution at
+// a single training point.
+type LossTerm struct {
+	Name   string
+	Func   Func
+	Weight float64
+	// partials of Func w.r.t. each network weight - built on first use
+	partials []Func
+}
+
+// Loss is a weighted sum of named loss terms.  If Balancer is non-nil, the term weights are
+// updated between training rounds by Network.TrainLoss.
+type Loss struct {
+	Terms    []*LossTerm
+	Balancer Balancer
+	// Out is where per-term loss reports are written during training - os.Stdout if nil.
+	Out io.Writer
+}
+
+// Add appends a new term to the loss with the given initial weight.
+func (l *Loss) Add(name string, f Func, weight float64) *LossTerm {
+	term := &LossTerm{Name: name, Func: f, Weight: weight}
+	l.Terms = append(l.Terms, term)
+	return term
+}
+
+// AddSystem adds the squared residual of each equation in the system as a separate term.
+func (l *Loss) AddSystem(s System) {
+	for _, eq := range s {
+		l.Add(eq.Name, &Pow{eq.Residual, Constant(2)}, eq.Weight)
+	}
+}
+
+// Term returns the named term or nil if it doesn't exist.
+func (l *Loss) Term(name string) *LossTerm {
+	for _, term := range l.Terms {
+		if term.Name == name {
+			return term
+		}
+	}
+	return nil
+}
+
+// Func returns the weighted sum of all the loss terms using their current weights.
+func (l *Loss) Func() Func {
+	var sum Sum
+	for _, term := range l.Terms {
+		sum = append(sum, Mult{Constant(term.Weight), term.Func})
+	}
+	return sum
+}
+
+// TermStats holds the (unweighted) value of a loss term summed over all training points (scaled
+// by any PointWeights like the network's cost) along with its gradient w.r.t. the network's
+// trainable variables (weights followed by params).
+type TermStats struct {
+	Value float64
+	Grad  []float64
+}
+
+// Stats evaluates every loss term and its weight gradient over the network's training data at
+// the network's current weights.
+func (l *Loss) Stats(n *Network) []TermStats {
+	n.initState()
+	stats := make([]TermStats, len(l.Terms))
+	for i, term := range l.Terms {
+		if term.partials == nil {
+			for _, w := range n.trainable() {
+				term.partials = append(term.partials, term.Func.Partial(w))
+			}
+		}
+		stats[i].Grad = make([]float64, len(term.partials))
+		for k := range n.TrainData {
+			n.setPoint(k)
+			w := n.pointWeight(k)
+			stats[i].Value += w * term.Func.Val(n.state)
+			for j, p := range term.partials {
+				stats[i].Grad[j] += w * p.Val(n.state)
+			}
+		}
+	}
+	return stats
+}
+
+// Report writes the value, weight and weighted value of each loss term.
+func (l *Loss) Report(w io.Writer, stats []TermStats) {
+	fmt.Fprintf(w, "Loss Terms:\n")
+	tot := 0.0
+	for i, term := range l.Terms {
+		weighted := term.Weight * stats[i].Value
+		tot += weighted
+		fmt.Fprintf(w, "    %v: %v (weight %v, weighted %v)\n", term.Name, stats[i].Value, term.Weight, weighted)
+	}
+	fmt.Fprintf(w, "    total: %v\n", tot)
+}
+
+// Balancer updates loss term weights between training rounds.
+type Balancer interface {
+	// Balance updates the weights of l's terms given the stats of each term at the current
+	// network weights.
+	Balance(l *Loss, stats []TermStats)
+}
+
+// GradNormBalance sets each term's weight so that all weighted term gradients have the same
+// norm as the first (reference) term's gradient.  The first term's weight is left unchanged.
+type GradNormBalance struct{}
+
+func (GradNormBalance) Balance(l *Loss, stats []TermStats) {
+	ref := l.Terms[0].Weight * norm(stats[0].Grad)
+	for i, term := range l.Terms[1:] {
+		if gnorm := norm(stats[i+1].Grad); gnorm > 0 {
+			term.Weight = ref / gnorm
+		}
+	}
+}
+
+// AnnealBalance implements the learning rate annealing scheme of Wang, Teng and Perdikaris
+// (2021).  Each term's target weight is the ratio of the max gradient magnitude of the first
+// (reference) term to the mean gradient magnitude of the term.  Weights are moved toward their
+// targets with a moving average: w = (1-Alpha)*w + Alpha*target.
+type AnnealBalance struct {
+	Alpha float64
+}
+
+func (b AnnealBalance) Balance(l *Loss, stats []TermStats) {
+	refmax := 0.0
+	for _, g := range stats[0].Grad {
+		refmax = math.Max(refmax, math.Abs(g))
+	}
+	refmax *= l.Terms[0].Weight
+	for i, term := range l.Terms[1:] {
+		mean := 0.0
+		for _, g := range stats[i+1].Grad {
+			mean += math.Abs(g)
+		}
+		mean /= float64(len(stats[i+1].Grad))
+		if mean > 0 {
+			term.Weight = (1-b.Alpha)*term.Weight + b.Alpha*refmax/mean
+		}
+	}
+}
+
+// SelfAdaptive treats the term weights as trainable parameters that maximize the loss (while the
+// network weights minimize it).  Since the gradient of the loss w.r.t. a term's weight is the
+// term's value, each round takes a gradient ascent step of w += Rate*value - so terms that stay
+// poorly satisfied have their weights grow.
+type SelfAdaptive struct {
+	Rate float64
+}
+
+func (b SelfAdaptive) Balance(l *Loss, stats []TermStats) {
+	for i, term := range l.Terms {
+		term.Weight += b.Rate * stats[i].Value
+	}
+}
+
+func norm(v []float64) float64 {
+	tot := 0.0
+	for _, val := range v {
+		tot += val * val
+	}
+	return math.Sqrt(tot)
+}
+
+// TrainLoss trains the network to minimize l for the given number of rounds.  Each round after
+// the first starts from the weights of the previous round and the per-term losses are reported
+// after every round.  If l has a Balancer, it updates the term weights between rounds.  It stops
+// and returns NonFinite if any round's training fails.
+func (n *Network) TrainLoss(l *Loss, rounds int) error {
+	out := l.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	for round := 0; round < rounds; round++ {
+		n.CostFunc = l.Func()
+		n.partials = nil
+		n.Train()
+		if n.NonFinite != nil {
+			return n.NonFinite
+		}
+
+		stats := l.Stats(n)
+		fmt.Fprintf(out, "Round %v:\n", round+1)
+		l.Report(out, stats)
+		n.ReportRegularizers(out)
+		if l.Balancer != nil && round < rounds-1 {
+			l.Balancer.Balance(l, stats)
+		}
+	}
+	return nil
+}
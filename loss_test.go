@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLossStats(t *testing.T) {
+	var w Variable = 0
+	var xv Variable = 1
+	net := &Network{
+		nextVarIndex: 2,
+		Weights:      []Variable{w},
+		Vars:         []Variable{xv},
+		TrainData:    [][]float64{{1}, {2}},
+		state:        []float64{3, 0},
+	}
+
+	var loss Loss
+	// (w*x)^2 and (w-1)^2
+	loss.Add("a", &Pow{Mult{w, xv}, Constant(2)}, 1)
+	loss.Add("b", &Pow{Sum{w, Constant(-1)}, Constant(2)}, 2)
+
+	stats := loss.Stats(net)
+	want := []TermStats{
+		{Value: 9*1 + 9*4, Grad: []float64{2*3*1 + 2*3*4}},
+		{Value: 2 * 4, Grad: []float64{2 * 2 * 2}},
+	}
+	for i := range want {
+		if math.Abs(stats[i].Value-want[i].Value) > 1e-10 || math.Abs(stats[i].Grad[0]-want[i].Grad[0]) > 1e-10 {
+			t.Errorf("term %v: want %+v, got %+v", loss.Terms[i].Name, want[i], stats[i])
+		}
+	}
+
+	if got, want := loss.Func().Val([]float64{3, 2}), 36.0+2*4; math.Abs(got-want) > 1e-10 {
+		t.Errorf("weighted loss: want %v, got %v", want, got)
+	}
+}
+
+func TestBalancers(t *testing.T) {
+	stats := []TermStats{
+		{Value: 1, Grad: []float64{3, -4}},
+		{Value: 2, Grad: []float64{1, 0}},
+	}
+	newLoss := func() *Loss {
+		l := &Loss{}
+		l.Add("a", Constant(0), 2)
+		l.Add("b", Constant(0), 1)
+		return l
+	}
+
+	tests := []struct {
+		Name     string
+		Balancer Balancer
+		Want     []float64
+	}{
+		{"GradNorm", GradNormBalance{}, []float64{2, 10}},
+		{"Anneal", AnnealBalance{Alpha: 0.5}, []float64{2, 0.5*1 + 0.5*8/0.5}},
+		{"SelfAdaptive", SelfAdaptive{Rate: 0.1}, []float64{2.1, 1.2}},
+	}
+
+	for _, test := range tests {
+		l := newLoss()
+		test.Balancer.Balance(l, stats)
+		for i, term := range l.Terms {
+			if math.Abs(term.Weight-test.Want[i]) > 1e-10 {
+				t.Errorf("%v term %v: want weight %v, got %v", test.Name, term.Name, test.Want[i], term.Weight)
+			}
+		}
+	}
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
-	"bytes"
-	"flag"
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
 	"os"
-	"os/exec"
 
 	"gonum.org/v1/gonum/optimize"
 )
@@ -113,10 +111,11 @@ type Mult []Func
 func (m Mult) Val(x []float64) float64 {
 	tot := 1.0
 	for _, fn := range m {
-		if fn.Val(x) == 0 {
+		val := fn.Val(x)
+		if val == 0 {
 			return 0
 		}
-		tot *= fn.Val(x)
+		tot *= val
 	}
 	return tot
 }
@@ -239,6 +238,10 @@ func (p *Pow) Val(x []float64) float64 {
 }
 
 func (p *Pow) Partial(v Variable) Func {
+	if c, ok := p.Exponent.(Constant); ok {
+		// power rule - the general form has ln(|base|) which is -Inf at a zero base
+		return Mult{p.Base.Partial(v), c, &Pow{p.Base, c - 1}}
+	}
 	return Mult{
 		p,
 		Sum{
@@ -273,14 +276,24 @@ func (b Branch) String() string { return "Branch(???)" }
 
 func Negative(f Func) Func { return Mult{Constant(-1), f} }
 func Inverse(f Func) Func  { return &Pow{f, Constant(-1)} }
-func Abs(f Func) Func {
+func Abs(f Func) Func      { return Absolute{f} }
+
+// Absolute is the absolute value of Func.
+type Absolute struct {
+	Func
+}
+
+func (a Absolute) Val(x []float64) float64 { return math.Abs(a.Func.Val(x)) }
+func (a Absolute) Partial(v Variable) Func {
 	return Branch(func(x []float64) Func {
-		if f.Val(x) >= 0 {
-			return f
+		if a.Func.Val(x) >= 0 {
+			return a.Func.Partial(v)
 		}
-		return Negative(f)
+		return Negative(a.Func.Partial(v))
 	})
 }
+func (a Absolute) String() string { return fmt.Sprintf("abs(%v)", a.Func) }
+func (a Absolute) Simplify() Func { return Absolute{a.Func.Simplify()} }
 
 type Tanh struct {
 	Func
@@ -300,6 +313,27 @@ func (t *Tanh) Partial(v Variable) Func {
 	}
 }
 
+type Sin struct {
+	Func
+}
+
+func (s Sin) Val(x []float64) float64 { return math.Sin(s.Func.Val(x)) }
+func (s Sin) Partial(v Variable) Func { return Mult{s.Func.Partial(v), Cos{s.Func}} }
+func (s Sin) String() string          { return fmt.Sprintf("sin(%v)", s.Func) }
+func (s Sin) Simplify() Func          { return Sin{s.Func.Simplify()} }
+
+type Cos struct {
+	Func
+}
+
+func (c Cos) Val(x []float64) float64 { return math.Cos(c.Func.Val(x)) }
+func (c Cos) Partial(v Variable) Func { return Mult{c.Func.Partial(v), Negative(Sin{c.Func})} }
+func (c Cos) String() string          { return fmt.Sprintf("cos(%v)", c.Func) }
+func (c Cos) Simplify() Func          { return Cos{c.Func.Simplify()} }
+
+// Exp returns e^f.
+func Exp(f Func) Func { return &Pow{Constant(math.E), f} }
+
 type Passthrough struct{ Func }
 
 func (p *Passthrough) SetInner(f Func) { p.Func = f }
@@ -313,77 +347,263 @@ type Network struct {
 	state        []float64
 	Outputs      []*Neuron
 	TrainData    [][]float64
-	partials     []Func
+	// Targets holds observed values for TargetVars at the corresponding TrainData points.  A nil
+	// row (or NaN entry) means there is no observation at that point.
+	Targets    [][]float64
+	TargetVars []Variable
+	// Params are unknown physical parameters that are trained along with the weights.
+	Params   []*Param
+	varNames map[Variable]string
+	// Method is the optimization method used for training - BFGS if nil.
+	Method optimize.Method
+	// Verbose enables printing of every training point as it is evaluated.
+	Verbose bool
+	// LossHistory is the cost after every major iteration of training.
+	LossHistory []float64
+	// Iterations is the total number of major optimizer iterations trained so far (including any
+	// restored from a checkpoint).  If MaxIterations is nonzero, training stops once Iterations
+	// reaches it.
+	Iterations    int
+	MaxIterations int
+	// CheckpointPath, if non-empty, is the file training writes a Checkpoint to every
+	// CheckpointEvery (default 10) major iterations and when training finishes.  With the default
+	// (nil) Method, checkpointed training uses an equivalent BFGS implementation whose state can
+	// be saved.
+	CheckpointPath  string
+	CheckpointEvery int
+	// Validation, if non-nil, holds out points that are monitored during training for early
+	// stopping.
+	Validation *Validation
+	// CheckFinite enables NaN/Inf diagnostics - the first non-finite cost or gradient value at a
+	// training point is diagnosed (see Diagnose) and stored in NonFinite and training stops,
+	// leaving the state at the offending weights.  Reporting NonFinite is left to the caller.
+	CheckFinite bool
+	NonFinite   *NonFiniteError
+	// PointWeights, if non-nil, weights each training point's CostFunc contribution - e.g.
+	// quadrature weights so the cost approximates an integral (see SetQuadrature).
+	PointWeights []float64
+	// Regularizers are penalties added to the cost of CostFunc over the training data.
+	Regularizers []*Regularizer
+	// resume is the optimizer state restored by LoadCheckpoint for the next training run.
+	resume   *Checkpoint
+	partials []Func
+	timeVar  Variable
+	hasTime  bool
 }
 
 func (n *Network) Cost(weights []float64) float64 {
-	for i, index := range n.Weights {
+	for i, index := range n.trainable() {
 		n.state[int(index)] = weights[i]
 	}
 	tot := 0.0
-	for _, pos := range n.TrainData {
-		fmt.Println("evaling position ", pos)
-		for i, index := range n.Vars {
-			n.state[int(index)] = pos[i]
+	for i, val := range valBatches([]Func{n.CostFunc}, n.pointStates("evaling position "))[0] {
+		c := n.pointWeight(i) * val
+		if n.CheckFinite && !isFinite(c) {
+			n.nonFinite(n.CostFunc, i, false, 0)
 		}
-		c := n.CostFunc.Val(n.state)
 		tot += c
 	}
-	return tot
+	if prior := n.priorFunc(); prior != nil {
+		tot += prior.Val(n.state)
+	}
+	return tot + n.regularization()
 }
 
 func (n *Network) CostGradient(gradw, weights []float64) {
+	vars := n.trainable()
 	if n.partials == nil {
-		for _, w := range n.Weights {
+		for _, w := range vars {
 			n.partials = append(n.partials, n.CostFunc.Partial(w))
 		}
 	}
 
-	for i, index := range n.Weights {
+	for i, index := range vars {
 		n.state[int(index)] = weights[i]
 		gradw[i] = 0
 	}
-	for _, pos := range n.TrainData {
-		fmt.Println("evaling gradient position ", pos)
-		for i, index := range n.Vars {
-			n.state[int(index)] = pos[i]
+	vals := valBatches(n.partials, n.pointStates("evaling gradient position "))
+	for i, p := range n.partials {
+		for j, val := range vals[i] {
+			g := n.pointWeight(j) * val
+			if n.CheckFinite && !isFinite(g) {
+				n.nonFinite(p, j, true, vars[i])
+			}
+			gradw[i] += g
 		}
-		for i, p := range n.partials {
-			gradw[i] += p.Val(n.state)
+	}
+	if prior := n.priorFunc(); prior != nil {
+		for i, v := range vars {
+			gradw[i] += prior.Partial(v).Val(n.state)
 		}
 	}
+	n.addRegGradient(gradw, vars)
+}
+
+// Train trains the network starting from its current weights - all ones (and params at their
+// initial values) for a new network.
+func (n *Network) Train() { n.train(n.trainableVals()) }
+
+// trainable returns all the variables adjusted by training - the network weights followed by
+// any physical parameters.
+func (n *Network) trainable() []Variable {
+	vars := append([]Variable{}, n.Weights...)
+	for _, p := range n.Params {
+		vars = append(vars, p.Var)
+	}
+	return vars
 }
 
-func (n *Network) Train() {
+func (n *Network) initState() {
 	if len(n.state) == 0 {
 		// initialize weights and vars input vector and set weights to 1
 		n.state = make([]float64, n.NVars())
 		for _, w := range n.Weights {
 			n.state[int(w)] = 1
 		}
+		for _, p := range n.Params {
+			n.state[int(p.Var)] = p.rawInit()
+		}
 	}
+}
+
+// RandomizeWeights sets all the network's weights to random values drawn from a standard normal
+// distribution.
+func (n *Network) RandomizeWeights(rng *rand.Rand) {
+	n.initState()
+	for _, w := range n.Weights {
+		n.state[int(w)] = rng.NormFloat64()
+	}
+}
+
+// trainableVals returns the current values of the network's trainable variables.
+func (n *Network) trainableVals() []float64 {
+	n.initState()
+	vars := n.trainable()
+	vals := make([]float64, len(vars))
+	for i, w := range vars {
+		vals[i] = n.state[int(w)]
+	}
+	return vals
+}
+
+func (n *Network) train(initx []float64) {
+	n.initState()
 
 	p := optimize.Problem{Func: n.Cost, Grad: n.CostGradient}
-	initx := make([]float64, len(n.Weights))
-	for i := range initx {
-		initx[i] = 1
+	n.NonFinite = nil
+	if v := n.Validation; v != nil {
+		v.reset()
+	}
+	if n.CheckFinite || n.Validation != nil {
+		p.Status = func() (optimize.Status, error) {
+			if n.NonFinite != nil {
+				return optimize.Failure, n.NonFinite
+			} else if v := n.Validation; v != nil && v.stopped {
+				return optimize.Success, nil
+			}
+			return optimize.NotTerminated, nil
+		}
 	}
 	settings := optimize.DefaultSettingsLocal()
-	result, err := optimize.Minimize(p, initx, settings, &optimize.BFGS{})
+	rec := &lossRecorder{n: n, resumed: n.resume != nil}
+	settings.Recorder = rec
+	if n.MaxIterations > 0 {
+		remaining := n.MaxIterations - n.Iterations
+		if remaining <= 0 {
+			return
+		}
+		// the starting location counts as a major iteration
+		settings.MajorIterations = remaining + 1
+	}
+	method := n.Method
+	if method == nil {
+		method = &optimize.BFGS{}
+		if n.CheckpointPath != "" || n.resume != nil {
+			rec.bfgs = newCheckpointBFGS(n.resume)
+			method = rec.bfgs
+		}
+	}
+	n.resume = nil
+
+	result, err := optimize.Minimize(p, initx, settings, method)
+	if n.NonFinite != nil {
+		// reported by the caller
+		return
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err = result.Status.Err(); err != nil {
+	if err = result.Status.Err(); err != nil && result.Status != optimize.IterationLimit {
 		log.Fatal(err)
 	}
-	for i := range result.X {
-		n.state[int(n.Weights[i])] = result.X[i]
+	for i, v := range n.trainable() {
+		n.state[int(v)] = result.X[i]
+	}
+	bfgs := rec.bfgs
+	if v := n.Validation; v != nil && v.Patience > 0 && v.best != nil {
+		for i, w := range n.trainable() {
+			n.state[int(w)] = v.best[i]
+		}
+		// the optimizer state belongs to the final weights rather than the restored ones
+		bfgs = nil
+	}
+	if n.CheckpointPath != "" {
+		if err := n.writeCheckpoint(n.trainableVals(), bfgs); err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	fmt.Printf("Stats:\n")
 	fmt.Printf("    Major Iterations: %v\n", result.MajorIterations)
 	fmt.Printf("    Func Evaluations: %v\n", result.FuncEvaluations)
 	fmt.Printf("    Grad Evaluations: %v\n", result.GradEvaluations)
+	if len(n.Params) > 0 {
+		fmt.Printf("Params:\n")
+		for _, p := range n.Params {
+			fmt.Printf("    %v: %v\n", p.Name, p.Val(n.state))
+		}
+	}
+}
+
+// lossRecorder appends the cost at every major optimizer iteration to the network's loss
+// history, counts iterations and writes periodic checkpoints.
+type lossRecorder struct {
+	n *Network
+	// resumed is true when continuing from a checkpoint - its starting location was already
+	// recorded by the interrupted run.
+	resumed bool
+	// seen is the number of major iterations recorded so far in this run.
+	seen int
+	bfgs *checkpointBFGS
+}
+
+func (r *lossRecorder) Init() error { return nil }
+func (r *lossRecorder) Record(loc *optimize.Location, op optimize.Operation, stats *optimize.Stats) error {
+	// the final major iteration is reported as a PostIteration
+	if op != optimize.MajorIteration && op != optimize.PostIteration || stats.MajorIterations == r.seen {
+		return nil
+	}
+	r.seen = stats.MajorIterations
+	if r.seen == 1 {
+		if r.resumed {
+			return nil
+		}
+	} else {
+		r.n.Iterations++
+	}
+	r.n.LossHistory = append(r.n.LossHistory, loc.F)
+	if r.n.Validation != nil {
+		r.n.Validation.check(r.n, loc.X)
+	}
+
+	every := r.n.CheckpointEvery
+	if every == 0 {
+		every = 10
+	}
+	if r.n.CheckpointPath != "" && r.n.Iterations > 0 && r.n.Iterations%every == 0 {
+		return r.n.writeCheckpoint(loc.X, r.bfgs)
+	}
+	return nil
 }
 
 func (n *Network) NVars() int { return n.nextVarIndex }
@@ -408,10 +628,16 @@ func (n *Network) NewNeuron() *Neuron {
 
 func (n *Network) NewInput() (*Neuron, Variable) {
 	v := n.addVar()
+	return n.NewInputFunc(v), v
+}
+
+// NewInputFunc creates an input neuron fed by f - typically a transformed input variable (see
+// Normalize and FourierFeatures).
+func (n *Network) NewInputFunc(f Func) *Neuron {
 	neuron := n.NewNeuron()
-	neuron.Inputs = append(neuron.Inputs, v)
+	neuron.Inputs = append(neuron.Inputs, f)
 	neuron.Weights = append(neuron.Weights, n.addWeight())
-	return neuron, v
+	return neuron
 }
 
 func (n *Network) NewOutputFunc(a ActivationFunc) *Neuron {
@@ -426,24 +652,91 @@ func (n *Network) NewOutput() *Neuron {
 	return neuron
 }
 
+// NewField creates a new (passthrough) output neuron that represents the named solution field
+// (e.g. "u", "v", "p") of a PDE system.
+func (n *Network) NewField(name string) *Neuron {
+	if n.Field(name) != nil {
+		panic("duplicate output field " + name)
+	}
+	neuron := n.NewOutput()
+	neuron.Name = name
+	return neuron
+}
+
+// Field returns the output neuron for the named field or nil if no such field exists.
+func (n *Network) Field(name string) *Neuron {
+	for _, out := range n.Outputs {
+		if out.Name == name {
+			return out
+		}
+	}
+	return nil
+}
+
+// FieldNames returns the names of all the network's outputs in the same order as Eval's result.
+// Unnamed outputs are labeled by their index (e.g. "out0").
+func (n *Network) FieldNames() []string {
+	names := make([]string, len(n.Outputs))
+	for i, out := range n.Outputs {
+		names[i] = out.Name
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("out%v", i)
+		}
+	}
+	return names
+}
+
+// Eval returns the value of every network output at the given input variable values.
+func (n *Network) Eval(inputVars []float64) []float64 {
+	for i, index := range n.Vars {
+		n.state[int(index)] = inputVars[i]
+	}
+	vals := make([]float64, len(n.Outputs))
+	for i, out := range n.Outputs {
+		vals[i] = out.Val(n.state)
+	}
+	return vals
+}
+
+// Equation is a single (named) residual in a system of PDEs.
+type Equation struct {
+	Name     string
+	Residual Func
+	Weight   float64
+}
+
+// System is a set of coupled PDE residuals that are solved together.
+type System []Equation
+
+// Add appends a residual with the given weight to the system.
+func (s *System) Add(name string, residual Func, weight float64) {
+	*s = append(*s, Equation{Name: name, Residual: residual, Weight: weight})
+}
+
+// Cost returns the weighted sum of the squared residuals of the system suitable for use as a
+// Network's CostFunc.
+func (s System) Cost() Func {
+	var cost Sum
+	for _, eq := range s {
+		cost = append(cost, Mult{Constant(eq.Weight), &Pow{eq.Residual, Constant(2)}})
+	}
+	return cost
+}
+
 type ActivationFunc interface {
 	Func
 	SetInner(f Func)
 }
 
 type Neuron struct {
+	Name       string
 	network    *Network
 	Inputs     []Func
 	Weights    []Variable
 	Activation ActivationFunc
 }
 
-func (n *Neuron) Eval(inputVars []float64) float64 {
-	for i, index := range n.network.Vars {
-		n.network.state[int(index)] = inputVars[i]
-	}
-	return n.Val(n.network.state)
-}
+func (n *Neuron) Eval(inputVars []float64) float64 { return n.network.EvalFunc(n, inputVars) }
 
 func (n *Neuron) PullFrom(neurons ...*Neuron) *Neuron {
 	for _, src := range neurons {
@@ -491,169 +784,89 @@ func Laplace(f Func, vars ...Variable) Func {
 	return sum
 }
 
-var plot = flag.String("plot", "", "'svg' to create svg plot with gnuplot")
-
-func main() {
-	flag.Parse()
-	prob1dDiscont()
-	//prob1d()
-	//prob2d()
-}
-
-func prob2d() {
-	var net Network
-	in1, var1 := net.NewInput()
-	in2, var2 := net.NewInput()
-	out1 := net.NewOutput().PullFrom(in1, in2)
-
-	// a PDE would be defined like follows
-	u, x, y := out1, var1, var2
-	forcingFunc := Constant(10)
-	diffusionCoeff := Constant(2)
-	residual := Sum{Mult{Laplace(u, x, y), diffusionCoeff}, Negative(forcingFunc)}
-	net.CostFunc = residual
-
-	// we can then use the residual as a cost function to update the weights using a
-	// backpropogation algorithm.
-
-	// build training data (input variable combos) and train the network
-	for xv := 0.0; xv < 5; xv += .1 {
-		for yv := 0.0; yv < 5; yv += .1 {
-			net.TrainData = append(net.TrainData, []float64{xv, yv})
-		}
+// Grad returns the gradient of f with respect to vars - one partial derivative per variable.
+func Grad(f Func, vars ...Variable) []Func {
+	grad := make([]Func, len(vars))
+	for i, v := range vars {
+		grad[i] = f.Partial(v)
 	}
+	return grad
+}
 
-	net.Train()
-
-	// look at the results
-	var buf bytes.Buffer
-	for xv := 0.0; xv < 5; xv += .1 {
-		for yv := 0.0; yv < 5; yv += .1 {
-			fmt.Fprintf(&buf, "%v %v %v\n", xv, yv, u.Eval([]float64{xv, yv}))
-		}
+// Div returns the divergence of the vector field fs where fs[i] is the component along vars[i].
+func Div(fs []Func, vars ...Variable) Func {
+	if len(fs) != len(vars) {
+		panic(fmt.Sprintf("Div: %v field components but %v variables", len(fs), len(vars)))
 	}
-
-	fmt.Println("Approximation Eqn: ", out1)
-	fmt.Println("Solution (x y u):")
-	fmt.Print(buf.String())
-
-	if *plot != "" {
-		cmd := exec.Command("gnuplot", "-e", `set terminal svg; set output "`+*plot+`"; plot "-" u 1:2:3 w image`)
-		cmd.Stdin = &buf
-		cmd.Stdout = os.Stdout
-		err := cmd.Run()
-		if err != nil {
-			log.Fatal(err)
-		}
+	var sum Sum
+	for i, v := range vars {
+		sum = append(sum, fs[i].Partial(v))
 	}
+	return sum
 }
 
-func prob1d() {
-	var net Network
-	in1, var1 := net.NewInput()
-	// This is a dummy input and variable to enable the network to output nonzero values when all
-	// inputs are zero.
-	dummyin, _ := net.NewInput()
-
-	out1 := net.NewOutput().PullFrom(in1, dummyin)
-
-	// a PDE would be defined like follows
-	u, x := out1, var1
-	_ = x
-	// we want to approximate u(x) = 3 so error=(u-3)^2
-	residual := &Pow{Sum{u, Constant(-3)}, Constant(2)}
-	net.CostFunc = residual
-
-	// build training data (input variable combos) and train the network
-	for xv := 0.0; xv < 5; xv += .1 {
-		// dummy input value corresponding to our dummy variable
-		dummy := 1.0
-		net.TrainData = append(net.TrainData, []float64{xv, dummy})
+// Curl returns the curl of the vector field fs.  For 3D fields the result has three components.
+// For 2D fields the result is the single (scalar) out-of-plane component.
+func Curl(fs []Func, vars ...Variable) []Func {
+	if len(fs) != len(vars) {
+		panic(fmt.Sprintf("Curl: %v field components but %v variables", len(fs), len(vars)))
 	}
-
-	net.Train()
-
-	// look at the results
-	var buf bytes.Buffer
-	for xv := 0.0; xv < 5; xv += .1 {
-		fmt.Fprintf(&buf, "%v\t%v\n", xv, u.Eval([]float64{xv, 1}))
+	diff := func(i, j int) Func {
+		return Sum{fs[j].Partial(vars[i]), Negative(fs[i].Partial(vars[j]))}
 	}
-
-	fmt.Println("Approximation Eqn: ", out1)
-	fmt.Println("Solution (x u):")
-	fmt.Print(buf.String())
+	switch len(vars) {
+	case 2:
+		return []Func{diff(0, 1)}
+	case 3:
+		return []Func{diff(1, 2), diff(2, 0), diff(0, 1)}
+	}
+	panic(fmt.Sprintf("Curl: undefined for %v dimensions", len(vars)))
 }
 
-func prob1dDiscont() {
-	var net Network
-	in1, var1 := net.NewInput()
-	// This is a dummy input and variable to enable the network to output nonzero values when all
-	// inputs are zero.
-	dummyin, _ := net.NewInput()
-
-	// hidden layer
-	n1 := net.NewNeuron().PullFrom(in1, dummyin)
-	n2 := net.NewNeuron().PullFrom(in1, dummyin)
-	n3 := net.NewNeuron().PullFrom(in1, dummyin)
-
-	out1 := net.NewOutput().PullFrom(n1, n2, n3)
-	//out1 := net.NewOutput().PullFrom(in1, dummyin)
-	fmt.Println("networkFunc: ", out1)
-
-	// convenient vars/names for building our PDE and BCs
-	u, x := out1, var1
-
-	// define boundary conditions
-	penalty := Constant(1000000.0)
-	bcs := Branch(func(xv []float64) Func {
-		if xv[int(x)] == 0 {
-			return Sum{Constant(0), Negative(u)}
-		} else if xv[int(x)] == 1 {
-			return Sum{Constant(0), Negative(u)}
-			return Sum{Constant(7), Negative(u)}
+// Hessian returns the matrix of second partial derivatives of f where entry [i][j] is
+// d^2f/(dvars[i] dvars[j]).
+func Hessian(f Func, vars ...Variable) [][]Func {
+	hess := make([][]Func, len(vars))
+	for i, vi := range vars {
+		dfi := f.Partial(vi)
+		hess[i] = make([]Func, len(vars))
+		for j, vj := range vars {
+			hess[i][j] = dfi.Partial(vj)
 		}
-		return Constant(0)
-	})
-
-	k := Branch(func(xv []float64) Func {
-		if xv[int(x)] < 0.5 {
-			return Constant(1)
-		}
-		return Constant(1)
-	})
-	heatSource := Constant(70)
-	// define our PDE: -k*laplace(u)=S --> residual R=k*laplace(u)+S
-	residual := Sum{Mult{k, Laplace(u, x)}, heatSource}
-
-	net.CostFunc = Sum{&Pow{residual, Constant(2)}, Mult{penalty, &Pow{bcs, Constant(2)}}}.Simplify()
-	fmt.Println("costfunc: ", net.CostFunc)
+	}
+	return hess
+}
 
-	// build training data (input variable combos) and train the network
-	dummyv := 1.0 // dummy input value corresponding to our dummy variable
-	// manually add boundary positions
-	net.TrainData = append(net.TrainData, []float64{0, dummyv})
-	net.TrainData = append(net.TrainData, []float64{1, dummyv})
-	for xv := 0.01; xv < 1; xv += .1 {
-		net.TrainData = append(net.TrainData, []float64{xv, dummyv})
+// Jacobian returns the matrix of partial derivatives of the functions fs where entry [i][j] is
+// dfs[i]/dvars[j].
+func Jacobian(fs []Func, vars ...Variable) [][]Func {
+	jac := make([][]Func, len(fs))
+	for i, f := range fs {
+		jac[i] = Grad(f, vars...)
 	}
+	return jac
+}
 
-	net.Train()
+// DirDeriv returns the derivative of f along the direction dir (which is not normalized) where
+// dir[i] is the direction component along vars[i].
+func DirDeriv(f Func, dir []Func, vars ...Variable) Func {
+	return Dot(dir, Grad(f, vars...))
+}
 
-	// look at the results
-	var buf bytes.Buffer
-	for xv := 0.0; xv <= 1.1; xv += .01 {
-		fmt.Fprintf(&buf, "%v\t%v\n", xv, u.Eval([]float64{xv, dummyv}))
+// Dot returns the inner product of a and b.
+func Dot(a, b []Func) Func {
+	if len(a) != len(b) {
+		panic(fmt.Sprintf("Dot: mismatched lengths %v and %v", len(a), len(b)))
 	}
+	var sum Sum
+	for i := range a {
+		sum = append(sum, Mult{a[i], b[i]})
+	}
+	return sum
+}
 
-	fmt.Println("Approximation Eqn: ", out1)
-	fmt.Println("Solution (x u):")
-	fmt.Print(buf.String())
-
-	cmd := exec.Command("gnuplot", "-e", `set terminal svg; set output "`+*plot+`"; plot "-" u 1:2 w l`)
-	cmd.Stdin = &buf
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
+func main() {
+	if err := runCLI(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -205,3 +205,97 @@ func permute(maxsum int, dimensions []int, prefix []int) [][]int {
 	}
 	return set
 }
+
+func TestOperators(t *testing.T) {
+	var z Variable = 2
+	// f = x^2*y + y*z
+	f := Sum{Mult{&Pow{x, Constant(2)}, y}, Mult{y, z}}
+	// F = (y*z, x*z, x*y^2)
+	F := []Func{Mult{y, z}, Mult{x, z}, Mult{x, &Pow{y, Constant(2)}}}
+	pt := []float64{2, 3, 5}
+	xv, yv, zv := pt[0], pt[1], pt[2]
+
+	tests := []struct {
+		Name string
+		Got  []Func
+		Want []float64
+	}{
+		{"Grad", Grad(f, x, y, z), []float64{2 * xv * yv, xv*xv + zv, yv}},
+		{"Div", []Func{Div(F, x, y, z)}, []float64{0}},
+		{"Curl", Curl(F, x, y, z), []float64{2*xv*yv - xv, yv - yv*yv, zv - zv}},
+		{"Curl2D", Curl(F[:2], x, y), []float64{0}},
+		{"Hessian", Hessian(f, x, y, z)[0], []float64{2 * yv, 2 * xv, 0}},
+		{"Jacobian", Jacobian(F, x, y, z)[2], []float64{yv * yv, 2 * xv * yv, 0}},
+		{"DirDeriv", []Func{DirDeriv(f, []Func{Constant(1), Constant(0), y}, x, y, z)}, []float64{2*xv*yv + yv*yv}},
+	}
+
+	for _, test := range tests {
+		if len(test.Got) != len(test.Want) {
+			t.Errorf("%v: want %v components, got %v", test.Name, len(test.Want), len(test.Got))
+			continue
+		}
+		for i, fn := range test.Got {
+			if got := fn.Val(pt); math.Abs(got-test.Want[i]) > 1e-10 {
+				t.Errorf("%v[%v]: want %v, got %v", test.Name, i, test.Want[i], got)
+			}
+		}
+	}
+}
+
+func TestSystem(t *testing.T) {
+	var net Network
+	in1, x := net.NewInput()
+	u := net.NewField("u").PullFrom(in1)
+	v := net.NewField("v").PullFrom(in1)
+	net.state = make([]float64, net.NVars())
+	for _, w := range net.Weights {
+		net.state[int(w)] = 1
+	}
+
+	if got := net.Field("v"); got != v {
+		t.Errorf("Field(\"v\"): want %p, got %p", v, got)
+	}
+
+	var sys System
+	sys.Add("eq1", Sum{u, Constant(-2)}, 1)
+	sys.Add("eq2", Sum{v.Partial(x), Constant(-3)}, 10)
+
+	xv := 0.5
+	vals := net.Eval([]float64{xv})
+	if len(vals) != 2 {
+		t.Fatalf("want 2 output values, got %v", len(vals))
+	}
+
+	// each field is tanh(x)*w with unit weights
+	want := math.Tanh(xv)
+	for i, name := range net.FieldNames() {
+		if math.Abs(vals[i]-want) > 1e-10 {
+			t.Errorf("field %v: want %v, got %v", name, want, vals[i])
+		}
+	}
+
+	dtanh := 1 - want*want
+	wantCost := math.Pow(want-2, 2) + 10*math.Pow(dtanh-3, 2)
+	if got := sys.Cost().Val(net.state); math.Abs(got-wantCost) > 1e-10 {
+		t.Errorf("system cost: want %v, got %v", wantCost, got)
+	}
+}
+
+func TestTrig(t *testing.T) {
+	// sin(x*y) + cos(x)^2 + e^y
+	f := Sum{Sin{Mult{x, y}}, &Pow{Cos{x}, Constant(2)}, Exp(y)}
+	pt := []float64{0.3, 0.7}
+	xv, yv := pt[0], pt[1]
+	want := math.Sin(xv*yv) + math.Pow(math.Cos(xv), 2) + math.Exp(yv)
+	if got := f.Val(pt); math.Abs(got-want) > 1e-10 {
+		t.Errorf("f: want %v, got %v", want, got)
+	}
+	wantdx := yv*math.Cos(xv*yv) - 2*math.Cos(xv)*math.Sin(xv)
+	if got := f.Partial(x).Val(pt); math.Abs(got-wantdx) > 1e-10 {
+		t.Errorf("df/dx: want %v, got %v", wantdx, got)
+	}
+	wantdy := xv*math.Cos(xv*yv) + math.Exp(yv)
+	if got := f.Partial(y).Val(pt); math.Abs(got-wantdy) > 1e-10 {
+		t.Errorf("df/dy: want %v, got %v", wantdy, got)
+	}
+}
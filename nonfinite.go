@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// NonFiniteError describes where a NaN or Inf first appeared while evaluating a Func.
+type NonFiniteError struct {
+	// Node is the innermost expression whose value is not finite although all its operands are.
+	Node Func
+	// Value is Node's value and Operands the values of its operands.
+	Value    float64
+	Operands []float64
+	// Point is the index of the training point being evaluated (-1 outside of training) and
+	// Inputs are its input values.
+	Point  int
+	Inputs []float64
+	// Grad is true if the value appeared in the cost gradient - in the derivative w.r.t. Wrt.
+	Grad bool
+	Wrt  Variable
+}
+
+func (e *NonFiniteError) Error() string {
+	where := "cost"
+	if e.Grad {
+		where = fmt.Sprintf("cost gradient (d/d%v)", e.Wrt)
+	}
+	msg := fmt.Sprintf("%v in %v from node %v with operands %v", e.Value, where, e.Node, e.Operands)
+	if e.Point >= 0 {
+		msg += fmt.Sprintf(" at training point %v (inputs %v)", e.Point, e.Inputs)
+	}
+	return msg
+}
+
+func isFinite(v float64) bool { return !math.IsNaN(v) && !math.IsInf(v, 0) }
+
+// Diagnose evaluates f at x and, if the result is NaN or Inf, returns the innermost node that
+// produced it.  It returns nil if f's value is finite.
+func Diagnose(f Func, x []float64) *NonFiniteError {
+	val := f.Val(x)
+	if isFinite(val) {
+		return nil
+	}
+	ops := operands(f, x)
+	var vals []float64
+	for _, op := range ops {
+		if e := Diagnose(op, x); e != nil {
+			return e
+		}
+		vals = append(vals, op.Val(x))
+	}
+	return &NonFiniteError{Node: f, Value: val, Operands: vals, Point: -1}
+}
+
+// operands returns the subexpressions f's value at x is computed from.
+func operands(f Func, x []float64) []Func {
+	switch f := f.(type) {
+	case Sum:
+		return f
+	case Mult:
+		return f
+	case Ln:
+		return []Func{f.Func}
+	case *Pow:
+		return []Func{f.Base, f.Exponent}
+	case Absolute:
+		return []Func{f.Func}
+	case *Tanh:
+		return []Func{f.Func}
+	case Sin:
+		return []Func{f.Func}
+	case Cos:
+		return []Func{f.Func}
+	case *Passthrough:
+		return []Func{f.Func}
+	case Branch:
+		return []Func{f(x)}
+	case *Neuron:
+		return []Func{f.getFunc()}
+	case *Param:
+		return []Func{f.value()}
+	case NamedFunc:
+		return []Func{f.Func}
+	}
+	return nil
+}
+
+// nonFinite records the first non-finite value found in the cost (or its gradient if grad is
+// true) at training point i - see Network.CheckFinite.
+func (n *Network) nonFinite(f Func, i int, grad bool, wrt Variable) {
+	if n.NonFinite != nil {
+		return
+	}
+	n.setPoint(i)
+	e := Diagnose(f, n.state)
+	if e == nil {
+		return
+	}
+	e.Point, e.Inputs, e.Grad, e.Wrt = i, n.TrainData[i], grad, wrt
+	n.NonFinite = e
+}
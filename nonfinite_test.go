@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestPowerRule(t *testing.T) {
+	zero := []float64{0, 0}
+	if got := (&Pow{x, Constant(2)}).Partial(x).Partial(x).Val(zero); got != 2 {
+		t.Errorf("d2/dx2 x^2 at 0: want 2, got %v", got)
+	}
+	if got := (&Pow{Sum{x, y}, Constant(3)}).Partial(y).Partial(x).Val(zero); got != 0 {
+		t.Errorf("d2/dxdy (x+y)^3 at 0: want 0, got %v", got)
+	}
+	if err := CheckGradient(&Pow{Mult{x, y}, Constant(-1.5)}, []float64{0.7, 1.3}, []Variable{x, y}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	inner := Ln{Sum{y, Constant(-1)}}
+	f := Sum{x, Mult{Constant(2), &Tanh{inner}}}
+	if e := Diagnose(f, []float64{1, 2}); e != nil {
+		t.Errorf("want nil for a finite value, got %v", e)
+	}
+	e := Diagnose(f, []float64{1, 0.5})
+	if e == nil {
+		t.Fatalf("want NaN from %v, got nil", inner)
+	}
+	if _, isLn := e.Node.(Ln); !isLn || len(e.Operands) != 1 || e.Operands[0] != -0.5 || !math.IsNaN(e.Value) {
+		t.Errorf("want NaN from %v with operand -0.5, got %+v", inner, e)
+	}
+}
+
+func TestCheckFinite(t *testing.T) {
+	var net Network
+	in, _ := net.NewInput()
+	u := net.NewField("u").PullFrom(in)
+	root := &Pow{u, Constant(0.5)}
+	net.CostFunc = root
+	net.TrainData = [][]float64{{0.5}, {-0.5}, {1}}
+	net.CheckFinite = true
+	net.Train()
+
+	e := net.NonFinite
+	if e == nil {
+		t.Fatalf("want a non-finite diagnosis, got nil")
+	}
+	if e.Point != 1 || e.Inputs[0] != -0.5 || e.Grad || e.Node != Func(root) {
+		t.Errorf("want NaN from %v at training point 1 (-0.5), got %v", root, e)
+	}
+}
+
+func TestNonFiniteTrainers(t *testing.T) {
+	build := func(cfg Config) *Setup {
+		var net Network
+		in, _ := net.NewInput()
+		u := net.NewField("u").PullFrom(in)
+		net.CostFunc = &Pow{u, Constant(0.5)}
+		net.TrainData = [][]float64{{0.5}, {-0.5}, {1}}
+		net.CheckFinite = true
+		return &Setup{Net: &net, Fields: []NamedFunc{{"u", u}}, Lo: []float64{-1}, Hi: []float64{1}, EvalRes: 3}
+	}
+
+	s := build(Config{})
+	l := &Loss{Out: ioutil.Discard}
+	l.Add("root", s.Net.CostFunc, 1)
+	if err := s.Net.TrainLoss(l, 2); err == nil {
+		t.Errorf("TrainLoss: want a non-finite error, got nil")
+	}
+	s = build(Config{})
+	if err := s.Net.TrainAdaptive(&Refiner{Candidates: s.EvalGrid(5), Add: 1}, 2); err == nil {
+		t.Errorf("TrainAdaptive: want a non-finite error, got nil")
+	}
+
+	st := &Study{Case: Case{Name: "root", Build: build}}
+	results, err := st.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Err == nil || !math.IsNaN(r.Loss) {
+		t.Errorf("Study: want a failed run with NaN loss, got %+v", r)
+	}
+	if tbl := StudyTable(results); tbl.Rows[0][tbl.Col("failed")] != 1 {
+		t.Errorf("Study: want the run marked failed in %v", tbl.Rows[0])
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Param is an unknown physical parameter (e.g. a conductivity or source strength) that is
+// inferred by training it jointly with the network weights.  Param is a Func so it can be used
+// directly when building residuals.
+type Param struct {
+	Name string
+	// Var is the raw (unconstrained) trainable variable backing the parameter.
+	Var  Variable
+	Init float64
+
+	bounded bool
+	lo, hi  float64
+	prior   Func
+}
+
+// NewParam creates a new trainable parameter with the given initial value.
+func (n *Network) NewParam(name string, init float64) *Param {
+	p := &Param{Name: name, Var: Variable(n.nextVarIndex), Init: init}
+	n.nextVarIndex++
+	n.Params = append(n.Params, p)
+	return p
+}
+
+// SetBounds constrains the parameter to lie strictly within (lo, hi).  This is done by mapping
+// the raw variable through a scaled tanh so the optimizer remains unconstrained.  The parameter's
+// initial value must lie inside the bounds.
+func (p *Param) SetBounds(lo, hi float64) *Param {
+	if !(lo < p.Init && p.Init < hi) {
+		panic(fmt.Sprintf("param %v: initial value %v outside bounds (%v, %v)", p.Name, p.Init, lo, hi))
+	}
+	p.bounded, p.lo, p.hi = true, lo, hi
+	return p
+}
+
+// SetPrior adds a Gaussian prior with the given mean and standard deviation for the parameter.
+// This adds the negative log prior, 0.5*((p-mean)/stddev)^2, to the network's cost once (not per
+// training point).
+func (p *Param) SetPrior(mean, stddev float64) *Param {
+	p.prior = Mult{Constant(0.5), &Pow{Mult{Sum{p, Constant(-mean)}, Constant(1 / stddev)}, Constant(2)}}
+	return p
+}
+
+func (p *Param) value() Func {
+	if !p.bounded {
+		return p.Var
+	}
+	// lo + (hi-lo)*(1+tanh(v))/2
+	return Sum{Constant(p.lo), Mult{Constant((p.hi - p.lo) / 2), Sum{Constant(1), &Tanh{p.Var}}}}
+}
+
+// rawInit returns the initial value of the raw variable backing the parameter.
+func (p *Param) rawInit() float64 {
+	if !p.bounded {
+		return p.Init
+	}
+	return math.Atanh(2*(p.Init-p.lo)/(p.hi-p.lo) - 1)
+}
+
+func (p *Param) Val(x []float64) float64 { return p.value().Val(x) }
+func (p *Param) Partial(v Variable) Func { return p.value().Partial(v) }
+func (p *Param) Simplify() Func          { return p }
+func (p *Param) String() string          { return p.Name }
+
+// ParamValues returns the current (e.g. fitted after Train) value of each parameter by name.
+func (n *Network) ParamValues() map[string]float64 {
+	n.initState()
+	vals := map[string]float64{}
+	for _, p := range n.Params {
+		vals[p.Name] = p.Val(n.state)
+	}
+	return vals
+}
+
+// priorFunc returns the sum of all the parameters' prior terms or nil if there are none.
+func (n *Network) priorFunc() Func {
+	var sum Sum
+	for _, p := range n.Params {
+		if p.prior != nil {
+			sum = append(sum, p.prior)
+		}
+	}
+	if len(sum) == 0 {
+		return nil
+	}
+	return sum
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParamBounds(t *testing.T) {
+	var net Network
+	p := net.NewParam("k", 2).SetBounds(1, 5)
+	net.initState()
+
+	if got := p.Val(net.state); math.Abs(got-2) > 1e-10 {
+		t.Errorf("initial value: want 2, got %v", got)
+	}
+	for _, raw := range []float64{-100, -1, 0, 1, 100} {
+		net.state[int(p.Var)] = raw
+		if got := p.Val(net.state); got < 1 || got > 5 {
+			t.Errorf("raw value %v: got %v outside bounds [1, 5]", raw, got)
+		}
+	}
+}
+
+func TestParamFit(t *testing.T) {
+	var net Network
+	_, x := net.NewInput()
+	a := net.NewParam("a", 1).SetBounds(0, 10)
+	b := net.NewParam("b", 1)
+	target := net.NewTarget()
+	net.CostFunc = DataMisfit(Sum{Mult{a, x}, b}, target, MSE)
+	for xv := 0.0; xv <= 1; xv += .25 {
+		net.AddSample([]float64{xv}, []float64{3*xv - 2})
+	}
+
+	net.Train()
+
+	vals := net.ParamValues()
+	if math.Abs(vals["a"]-3) > 1e-4 || math.Abs(vals["b"]+2) > 1e-4 {
+		t.Errorf("want a=3 and b=-2, got %v", vals)
+	}
+}
+
+func TestParamPrior(t *testing.T) {
+	var net Network
+	_, x := net.NewInput()
+	a := net.NewParam("a", 1).SetPrior(4, 1)
+	target := net.NewTarget()
+	net.CostFunc = DataMisfit(Mult{a, x}, target, MSE)
+	// a single observation pulling a toward 2 - the prior pulls it toward 4
+	net.AddSample([]float64{1}, []float64{2})
+
+	net.Train()
+
+	// minimizes (a-2)^2 + 0.5*(a-4)^2
+	want := 8.0 / 3
+	if got := net.ParamValues()["a"]; math.Abs(got-want) > 1e-4 {
+		t.Errorf("want a=%v, got %v", want, got)
+	}
+}
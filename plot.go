@@ -0,0 +1,558 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Plot is a 2D chart of lines and/or a heatmap that is rendered to SVG or PNG in pure Go - no
+// external plotting tools are required.
+type Plot struct {
+	Title  string
+	XLabel string
+	YLabel string
+	// LogX and LogY plot the respective axis on a log10 scale.  Nonpositive values are dropped.
+	LogX, LogY bool
+	Lines      []Line
+	Heat       *Heatmap
+	// Contours is the number of evenly spaced contour levels drawn over the heatmap (if any).
+	Contours int
+}
+
+// Line is a named series of (X[i], Y[i]) points connected by straight segments.
+type Line struct {
+	Name string
+	X, Y []float64
+}
+
+// Heatmap is a function sampled on a rectilinear grid where Z[j][i] is the value at (X[i], Y[j]).
+type Heatmap struct {
+	X, Y []float64
+	Z    [][]float64
+}
+
+// Save renders the plot to the named file as SVG or PNG depending on the file extension.
+func (p *Plot) Save(path string, width, height int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".svg":
+		err = p.WriteSVG(f, width, height)
+	case ".png":
+		err = p.WritePNG(f, width, height)
+	default:
+		err = fmt.Errorf("unsupported plot format '%v'", ext)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WriteSVG renders the plot as an SVG image.
+func (p *Plot) WriteSVG(w io.Writer, width, height int) error {
+	c := &svgCanvas{w: bufio.NewWriter(w)}
+	fmt.Fprintf(c.w, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v">`+"\n", width, height, width, height)
+	c.rect(0, 0, float64(width), float64(height), color.RGBA{255, 255, 255, 255})
+	p.draw(c, float64(width), float64(height))
+	fmt.Fprintln(c.w, "</svg>")
+	return c.w.Flush()
+}
+
+// WritePNG renders the plot as a PNG image.  Text is drawn with a small built-in pixel font
+// (letters are drawn in upper case).
+func (p *Plot) WritePNG(w io.Writer, width, height int) error {
+	c := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.rect(0, 0, float64(width), float64(height), color.RGBA{255, 255, 255, 255})
+	p.draw(c, float64(width), float64(height))
+	return png.Encode(w, c.img)
+}
+
+// canvas is a drawing surface with the origin in the top-left corner.
+type canvas interface {
+	line(x1, y1, x2, y2 float64, c color.RGBA)
+	rect(x, y, w, h float64, c color.RGBA)
+	// text draws s with its baseline at y. anchor is one of "start", "middle" or "end".
+	text(x, y float64, s string, anchor string, vertical bool)
+}
+
+var lineColors = []color.RGBA{
+	{31, 119, 180, 255},
+	{255, 127, 14, 255},
+	{44, 160, 44, 255},
+	{214, 39, 40, 255},
+	{148, 103, 189, 255},
+	{140, 86, 75, 255},
+}
+
+var black = color.RGBA{0, 0, 0, 255}
+
+func (p *Plot) scale(v float64, log bool) float64 {
+	if log {
+		if v <= 0 {
+			return math.NaN()
+		}
+		return math.Log10(v)
+	}
+	return v
+}
+
+// bounds returns the data ranges (in scaled coordinates) covered by the plot.
+func (p *Plot) bounds() (xmin, xmax, ymin, ymax float64) {
+	xmin, ymin = math.Inf(1), math.Inf(1)
+	xmax, ymax = math.Inf(-1), math.Inf(-1)
+	grow := func(x, y float64) {
+		x, y = p.scale(x, p.LogX), p.scale(y, p.LogY)
+		if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			return
+		}
+		xmin, xmax = math.Min(xmin, x), math.Max(xmax, x)
+		ymin, ymax = math.Min(ymin, y), math.Max(ymax, y)
+	}
+	for _, l := range p.Lines {
+		for i := range l.X {
+			grow(l.X[i], l.Y[i])
+		}
+	}
+	if p.Heat != nil {
+		for _, x := range p.Heat.X {
+			for _, y := range p.Heat.Y {
+				grow(x, y)
+			}
+		}
+	}
+
+	if xmin > xmax {
+		xmin, xmax = 0, 1
+	}
+	if ymin > ymax {
+		ymin, ymax = 0, 1
+	}
+	if xmin == xmax {
+		xmin, xmax = xmin-1, xmax+1
+	}
+	if ymin == ymax {
+		ymin, ymax = ymin-1, ymax+1
+	}
+	return xmin, xmax, ymin, ymax
+}
+
+func (p *Plot) draw(c canvas, width, height float64) {
+	const left, right, top, bottom = 70.0, 20.0, 40.0, 50.0
+	pw, ph := width-left-right, height-top-bottom
+	if p.Heat != nil {
+		// leave room for the color bar
+		pw -= 60
+	}
+	xmin, xmax, ymin, ymax := p.bounds()
+	px := func(x float64) float64 { return left + (p.scale(x, p.LogX)-xmin)/(xmax-xmin)*pw }
+	py := func(y float64) float64 { return top + ph - (p.scale(y, p.LogY)-ymin)/(ymax-ymin)*ph }
+
+	if p.Heat != nil {
+		p.drawHeat(c, px, py, left+pw, top, ph)
+	}
+
+	for i, l := range p.Lines {
+		col := lineColors[i%len(lineColors)]
+		for j := 1; j < len(l.X); j++ {
+			x1, y1, x2, y2 := px(l.X[j-1]), py(l.Y[j-1]), px(l.X[j]), py(l.Y[j])
+			if anyNaN(x1, y1, x2, y2) {
+				continue
+			}
+			c.line(x1, y1, x2, y2, col)
+		}
+	}
+
+	// axes box and ticks
+	c.line(left, top, left+pw, top, black)
+	c.line(left, top+ph, left+pw, top+ph, black)
+	c.line(left, top, left, top+ph, black)
+	c.line(left+pw, top, left+pw, top+ph, black)
+	for _, t := range ticks(xmin, xmax, p.LogX) {
+		x := left + (t.pos-xmin)/(xmax-xmin)*pw
+		c.line(x, top+ph, x, top+ph+5, black)
+		c.text(x, top+ph+18, t.label, "middle", false)
+	}
+	for _, t := range ticks(ymin, ymax, p.LogY) {
+		y := top + ph - (t.pos-ymin)/(ymax-ymin)*ph
+		c.line(left-5, y, left, y, black)
+		c.text(left-8, y+4, t.label, "end", false)
+	}
+
+	c.text(left+pw/2, top-15, p.Title, "middle", false)
+	c.text(left+pw/2, height-10, p.XLabel, "middle", false)
+	c.text(18, top+ph/2, p.YLabel, "middle", true)
+
+	// legend
+	ly := top + 15
+	for i, l := range p.Lines {
+		if l.Name == "" {
+			continue
+		}
+		col := lineColors[i%len(lineColors)]
+		c.line(left+pw-110, ly-4, left+pw-90, ly-4, col)
+		c.text(left+pw-85, ly, l.Name, "start", false)
+		ly += 15
+	}
+}
+
+func anyNaN(vals ...float64) bool {
+	for _, v := range vals {
+		if math.IsNaN(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plot) drawHeat(c canvas, px, py func(float64) float64, barx, top, ph float64) {
+	h := p.Heat
+	zmin, zmax := math.Inf(1), math.Inf(-1)
+	for _, row := range h.Z {
+		for _, z := range row {
+			if !math.IsNaN(z) {
+				zmin, zmax = math.Min(zmin, z), math.Max(zmax, z)
+			}
+		}
+	}
+	if zmin > zmax {
+		return
+	}
+	if zmin == zmax {
+		zmax = zmin + 1
+	}
+
+	// each cell is centered on its grid point and extends halfway to its neighbors
+	edges := func(vals []float64, i int) (float64, float64) {
+		lo, hi := vals[i], vals[i]
+		if i > 0 {
+			lo = (vals[i-1] + vals[i]) / 2
+		}
+		if i < len(vals)-1 {
+			hi = (vals[i] + vals[i+1]) / 2
+		}
+		return lo, hi
+	}
+	for j := range h.Y {
+		for i := range h.X {
+			x0, x1 := edges(h.X, i)
+			y0, y1 := edges(h.Y, j)
+			X0, X1, Y0, Y1 := px(x0), px(x1), py(y1), py(y0)
+			if anyNaN(X0, X1, Y0, Y1, h.Z[j][i]) {
+				continue
+			}
+			c.rect(X0, Y0, X1-X0+0.5, Y1-Y0+0.5, colormap((h.Z[j][i]-zmin)/(zmax-zmin)))
+		}
+	}
+
+	for k := 1; k <= p.Contours; k++ {
+		level := zmin + float64(k)/float64(p.Contours+1)*(zmax-zmin)
+		for _, seg := range contour(h, level) {
+			c.line(px(seg[0]), py(seg[1]), px(seg[2]), py(seg[3]), black)
+		}
+	}
+
+	// color bar
+	const n = 50
+	for k := 0; k < n; k++ {
+		y := top + ph - float64(k+1)/n*ph
+		c.rect(barx+20, y, 15, ph/n+0.5, colormap((float64(k)+0.5)/n))
+	}
+	c.text(barx+38, top+8, formatTick(zmax), "start", false)
+	c.text(barx+38, top+ph, formatTick(zmin), "start", false)
+}
+
+// colormap maps t in [0,1] to a blue-white-red diverging color.
+func colormap(t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t))
+	if t < 0.5 {
+		s := t / 0.5
+		return color.RGBA{uint8(59 + s*(221-59)), uint8(76 + s*(221-76)), uint8(192 + s*(221-192)), 255}
+	}
+	s := (t - 0.5) / 0.5
+	return color.RGBA{uint8(221 + s*(180-221)), uint8(221 + s*(4-221)), uint8(221 + s*(38-221)), 255}
+}
+
+// contour returns line segments (x1, y1, x2, y2) approximating the level set z == level of the
+// heatmap using marching squares.
+func contour(h *Heatmap, level float64) [][4]float64 {
+	var segs [][4]float64
+	interp := func(x1, y1, z1, x2, y2, z2 float64) (float64, float64) {
+		t := (level - z1) / (z2 - z1)
+		return x1 + t*(x2-x1), y1 + t*(y2-y1)
+	}
+	for j := 0; j < len(h.Y)-1; j++ {
+		for i := 0; i < len(h.X)-1; i++ {
+			// cell corners counter-clockwise from bottom-left
+			xs := [4]float64{h.X[i], h.X[i+1], h.X[i+1], h.X[i]}
+			ys := [4]float64{h.Y[j], h.Y[j], h.Y[j+1], h.Y[j+1]}
+			zs := [4]float64{h.Z[j][i], h.Z[j][i+1], h.Z[j+1][i+1], h.Z[j+1][i]}
+			var pts []float64
+			for e := 0; e < 4; e++ {
+				a, b := e, (e+1)%4
+				if (zs[a] < level) != (zs[b] < level) {
+					x, y := interp(xs[a], ys[a], zs[a], xs[b], ys[b], zs[b])
+					pts = append(pts, x, y)
+				}
+			}
+			// 2 crossings form one segment; 4 (a saddle) form two
+			for k := 0; k+3 < len(pts); k += 4 {
+				segs = append(segs, [4]float64{pts[k], pts[k+1], pts[k+2], pts[k+3]})
+			}
+		}
+	}
+	return segs
+}
+
+type tick struct {
+	pos   float64
+	label string
+}
+
+// ticks returns roughly 5 nicely rounded tick positions spanning [min, max].  For log axes,
+// positions are in log10 units and ticks are placed at integer powers of ten.
+func ticks(min, max float64, log bool) []tick {
+	if log {
+		var ts []tick
+		for e := math.Ceil(min); e <= max; e++ {
+			ts = append(ts, tick{e, formatTick(math.Pow(10, e))})
+		}
+		if len(ts) > 0 {
+			return ts
+		}
+	}
+
+	raw := (max - min) / 5
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := mag
+	for _, mult := range []float64{1, 2, 5, 10} {
+		if step = mult * mag; step >= raw {
+			break
+		}
+	}
+	var ts []tick
+	for v := math.Ceil(min/step) * step; v <= max+step*1e-9; v += step {
+		val := v
+		if log {
+			val = math.Pow(10, v)
+		}
+		ts = append(ts, tick{v, formatTick(val)})
+	}
+	return ts
+}
+
+func formatTick(v float64) string {
+	if math.Abs(v) < 1e-12 {
+		return "0"
+	}
+	return strings.Replace(fmt.Sprintf("%.3g", v), "e+0", "e", 1)
+}
+
+type svgCanvas struct {
+	w *bufio.Writer
+}
+
+func svgColor(c color.RGBA) string { return fmt.Sprintf("rgb(%v,%v,%v)", c.R, c.G, c.B) }
+
+func (c *svgCanvas) line(x1, y1, x2, y2 float64, col color.RGBA) {
+	fmt.Fprintf(c.w, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%v" stroke-width="1.5"/>`+"\n", x1, y1, x2, y2, svgColor(col))
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, col color.RGBA) {
+	fmt.Fprintf(c.w, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%v"/>`+"\n", x, y, w, h, svgColor(col))
+}
+
+func (c *svgCanvas) text(x, y float64, s string, anchor string, vertical bool) {
+	if s == "" {
+		return
+	}
+	transform := ""
+	if vertical {
+		transform = fmt.Sprintf(` transform="rotate(-90 %.2f %.2f)"`, x, y)
+	}
+	s = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	fmt.Fprintf(c.w, `<text x="%.2f" y="%.2f" font-family="sans-serif" font-size="12" text-anchor="%v"%v>%v</text>`+"\n", x, y, anchor, transform, s)
+}
+
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func (c *pngCanvas) line(x1, y1, x2, y2 float64, col color.RGBA) {
+	n := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))) + 1
+	for k := 0; k <= n; k++ {
+		t := float64(k) / float64(n)
+		c.img.SetRGBA(int(x1+t*(x2-x1)+0.5), int(y1+t*(y2-y1)+0.5), col)
+	}
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64, col color.RGBA) {
+	for i := int(x); i < int(math.Ceil(x+w)); i++ {
+		for j := int(y); j < int(math.Ceil(y+h)); j++ {
+			c.img.SetRGBA(i, j, col)
+		}
+	}
+}
+
+// text draws s using a tiny built-in 3x5 pixel font.  Lower case letters are drawn in upper case
+// and characters without a glyph as '?'.  Vertical text reads bottom to top.
+func (c *pngCanvas) text(x, y float64, s string, anchor string, vertical bool) {
+	const scale, advance = 2, 8
+	runes := []rune(s)
+	width := float64(len(runes) * advance)
+	shift := 0.0
+	switch anchor {
+	case "middle":
+		shift = width / 2
+	case "end":
+		shift = width
+	}
+	for k, r := range runes {
+		glyph, ok := pixelFont[r]
+		if !ok {
+			glyph, ok = pixelFont[unicode.ToUpper(r)]
+		}
+		if !ok {
+			glyph = pixelFont['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>uint(col)) == 0 {
+					continue
+				}
+				along, up := float64(k*advance+col*scale)-shift, float64((5-row)*scale)
+				if vertical {
+					c.rect(x-up, y-along-scale, scale, scale, black)
+				} else {
+					c.rect(x+along, y-up, scale, scale, black)
+				}
+			}
+		}
+	}
+}
+
+// pixelFont holds 3x5 pixel glyphs - one 3 bit row per entry, top to bottom.
+var pixelFont = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'.': {0, 0, 0, 0, 2},
+	'-': {0, 0, 7, 0, 0},
+	'+': {0, 2, 7, 2, 0},
+	'A': {2, 5, 7, 5, 5},
+	'B': {6, 5, 6, 5, 6},
+	'C': {3, 4, 4, 4, 3},
+	'D': {6, 5, 5, 5, 6},
+	'E': {7, 4, 6, 4, 7},
+	'F': {7, 4, 6, 4, 4},
+	'G': {3, 4, 5, 5, 3},
+	'H': {5, 5, 7, 5, 5},
+	'I': {7, 2, 2, 2, 7},
+	'J': {1, 1, 1, 5, 2},
+	'K': {5, 5, 6, 5, 5},
+	'L': {4, 4, 4, 4, 7},
+	'M': {5, 7, 7, 5, 5},
+	'N': {6, 5, 5, 5, 5},
+	'O': {2, 5, 5, 5, 2},
+	'P': {6, 5, 6, 4, 4},
+	'Q': {2, 5, 5, 6, 3},
+	'R': {6, 5, 6, 5, 5},
+	'S': {3, 4, 2, 1, 6},
+	'T': {7, 2, 2, 2, 2},
+	'U': {5, 5, 5, 5, 7},
+	'V': {5, 5, 5, 5, 2},
+	'W': {5, 5, 7, 7, 5},
+	'X': {5, 5, 2, 5, 5},
+	'Y': {5, 5, 2, 2, 2},
+	'Z': {7, 1, 2, 4, 7},
+	' ': {0, 0, 0, 0, 0},
+	'(': {1, 2, 2, 2, 1},
+	')': {4, 2, 2, 2, 4},
+	'[': {3, 2, 2, 2, 3},
+	']': {6, 2, 2, 2, 6},
+	'=': {0, 7, 0, 7, 0},
+	',': {0, 0, 0, 2, 4},
+	':': {0, 2, 0, 2, 0},
+	'_': {0, 0, 0, 0, 7},
+	'/': {1, 1, 2, 4, 4},
+	'^': {2, 5, 0, 0, 0},
+	'*': {0, 5, 2, 5, 0},
+	'|': {2, 2, 2, 2, 2},
+	'<': {1, 2, 4, 2, 1},
+	'>': {4, 2, 1, 2, 4},
+	'%': {5, 1, 2, 4, 5},
+	'?': {7, 1, 2, 0, 2},
+}
+
+// LinePlot evaluates f at each of the given points and plots it against the input variable x.
+func (n *Network) LinePlot(f Func, x Variable, pts [][]float64) *Plot {
+	xi := n.varIndex(x)
+	var l Line
+	if neuron, ok := f.(*Neuron); ok {
+		l.Name = neuron.Name
+	}
+	for _, pt := range pts {
+		l.X = append(l.X, pt[xi])
+	}
+	l.Y = n.EvalBatch(f, pts)
+	return &Plot{XLabel: n.VarName(x), Lines: []Line{l}}
+}
+
+// HeatmapPlot evaluates f on the grid of xs by ys values for input variables x and y and plots it
+// as a heatmap with contour lines.  Other input variables are held at their values in base.
+func (n *Network) HeatmapPlot(f Func, x, y Variable, base, xs, ys []float64) *Plot {
+	xi, yi := n.varIndex(x), n.varIndex(y)
+	h := &Heatmap{X: xs, Y: ys}
+	var pts [][]float64
+	for _, yv := range ys {
+		for _, xv := range xs {
+			pt := append([]float64{}, base...)
+			pt[xi], pt[yi] = xv, yv
+			pts = append(pts, pt)
+		}
+	}
+	vals := n.EvalBatch(f, pts)
+	for j := range ys {
+		h.Z = append(h.Z, vals[j*len(xs):(j+1)*len(xs)])
+	}
+	return &Plot{XLabel: n.VarName(x), YLabel: n.VarName(y), Heat: h, Contours: 8}
+}
+
+// LossPlot plots the training loss history (e.g. Network.LossHistory) on a log scale.
+func LossPlot(history []float64) *Plot {
+	l := Line{Name: "loss"}
+	for i, v := range history {
+		l.X = append(l.X, float64(i+1))
+		l.Y = append(l.Y, v)
+	}
+	return &Plot{Title: "Training Loss", XLabel: "iteration", YLabel: "loss", LogY: true, Lines: []Line{l}}
+}
+
+// varIndex returns the index of input variable v within n.Vars (i.e. within an input point).
+func (n *Network) varIndex(v Variable) int {
+	for i, vv := range n.Vars {
+		if vv == v {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("%v is not an input variable", v))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPlotRender(t *testing.T) {
+	xs := []float64{0, 0.5, 1, 1.5, 2}
+	h := &Heatmap{X: xs, Y: xs}
+	for _, y := range xs {
+		var row []float64
+		for _, x := range xs {
+			row = append(row, x*x+y)
+		}
+		h.Z = append(h.Z, row)
+	}
+
+	plots := map[string]*Plot{
+		"line": {Title: "a <line>", Lines: []Line{{Name: "u", X: xs, Y: []float64{3, 1, 4, 1, 5}}}},
+		"heat": {Heat: h, Contours: 4},
+		"loss": LossPlot([]float64{100, 10, 1, 0.01, 0}),
+	}
+	for name, p := range plots {
+		var buf bytes.Buffer
+		if err := p.WriteSVG(&buf, 320, 240); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		dec := xml.NewDecoder(&buf)
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%v: invalid svg: %v", name, err)
+			}
+		}
+
+		buf.Reset()
+		if err := p.WritePNG(&buf, 320, 240); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%v: invalid png: %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 240 {
+			t.Errorf("%v: want 320x240 png, got %vx%v", name, b.Dx(), b.Dy())
+		}
+	}
+
+	// png output includes the title and axis labels like svg
+	var plain, labeled bytes.Buffer
+	p := &Plot{Lines: []Line{{X: xs, Y: xs}}}
+	p.WritePNG(&plain, 320, 240)
+	p.Title, p.XLabel, p.YLabel = "Title", "x", "u(x)"
+	p.WritePNG(&labeled, 320, 240)
+	if bytes.Equal(plain.Bytes(), labeled.Bytes()) {
+		t.Errorf("png title and axis labels were not drawn")
+	}
+}
+
+func TestContour(t *testing.T) {
+	// z = x on a unit square - the 0.25 level set is the vertical line x = 0.25
+	h := &Heatmap{X: []float64{0, 0.5, 1}, Y: []float64{0, 1}, Z: [][]float64{{0, 0.5, 1}, {0, 0.5, 1}}}
+	segs := contour(h, 0.25)
+	if len(segs) != 1 {
+		t.Fatalf("want 1 contour segment, got %v", len(segs))
+	}
+	for _, x := range []float64{segs[0][0], segs[0][2]} {
+		if math.Abs(x-0.25) > 1e-10 {
+			t.Errorf("want contour at x=0.25, got segment %v", segs[0])
+		}
+	}
+}
+
+func TestTicks(t *testing.T) {
+	tests := []struct {
+		Min, Max float64
+		Log      bool
+		Want     string
+	}{
+		{0, 1, false, "0 0.2 0.4 0.6 0.8 1"},
+		{-3, 7, false, "-2 0 2 4 6"},
+		{-2, 1, true, "0.01 0.1 1 10"},
+	}
+	for _, test := range tests {
+		var labels []string
+		for _, tk := range ticks(test.Min, test.Max, test.Log) {
+			labels = append(labels, tk.label)
+		}
+		if got := strings.Join(labels, " "); got != test.Want {
+			t.Errorf("ticks(%v, %v, %v): want %v, got %v", test.Min, test.Max, test.Log, test.Want, got)
+		}
+	}
+}
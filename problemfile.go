@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProblemSpec is a declarative problem definition that can be read from a YAML or JSON file and
+// built into a ready to train network - see examples/ for sample files.  All expressions are
+// parsed with ParseExpr and may refer to inputs, fields, coefficients and params by name.
+type ProblemSpec struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	// Inputs are the problem's independent variables and their domain.
+	Inputs []InputSpec `json:"inputs" yaml:"inputs"`
+	// Fields are the names of the solution fields (one network output each).
+	Fields []string `json:"fields" yaml:"fields"`
+	// Coefficients are named constants that can be used in expressions.
+	Coefficients map[string]float64 `json:"coefficients" yaml:"coefficients"`
+	Params       []ParamSpec        `json:"params" yaml:"params"`
+	// Equations are residuals that apply everywhere in the domain.
+	Equations []EquationSpec `json:"equations" yaml:"equations"`
+	// Conditions are residuals (e.g. boundary and initial conditions) that apply only where
+	// their predicate holds.
+	Conditions []ConditionSpec `json:"conditions" yaml:"conditions"`
+	Network    NetworkSpec     `json:"network" yaml:"network"`
+	Sampler    SamplerSpec     `json:"sampler" yaml:"sampler"`
+	// Exact maps field names to known analytic solution expressions used to report error norms.
+	Exact map[string]string `json:"exact" yaml:"exact"`
+	// Optimizer is one of the 'adiff solve -optimizer' methods (default bfgs).
+	Optimizer string `json:"optimizer" yaml:"optimizer"`
+}
+
+// InputSpec is an input variable ranging over [Min, Max].  At most one input may be the time
+// coordinate.
+type InputSpec struct {
+	Name string  `json:"name" yaml:"name"`
+	Min  float64 `json:"min" yaml:"min"`
+	Max  float64 `json:"max" yaml:"max"`
+	Time bool    `json:"time" yaml:"time"`
+}
+
+// ParamSpec is a trainable parameter.  It is bounded if Min < Max.
+type ParamSpec struct {
+	Name string  `json:"name" yaml:"name"`
+	Init float64 `json:"init" yaml:"init"`
+	Min  float64 `json:"min" yaml:"min"`
+	Max  float64 `json:"max" yaml:"max"`
+}
+
+// EquationSpec is a residual expression that is driven to zero.  Weight defaults to 1.
+type EquationSpec struct {
+	Name     string  `json:"name" yaml:"name"`
+	Residual string  `json:"residual" yaml:"residual"`
+	Weight   float64 `json:"weight" yaml:"weight"`
+}
+
+// ConditionSpec is a residual that applies where the Where predicate (see ParsePredicate) holds
+// - e.g. "x == 0 || x == 1".  Weight defaults to 1.
+type ConditionSpec struct {
+	Name     string  `json:"name" yaml:"name"`
+	Where    string  `json:"where" yaml:"where"`
+	Residual string  `json:"residual" yaml:"residual"`
+	Weight   float64 `json:"weight" yaml:"weight"`
+}
+
+// NetworkSpec is the hidden layer architecture.  Zero values default to 3 neurons and 1 layer.
+// Normalize scales each input from [min, max] to [-1, 1] before the input neurons.  Fourier adds
+// input neurons for that many random Fourier features (see FourierFeatures) of the normalized
+// inputs with frequencies of standard deviation FourierScale (default 1).  Output, if set, maps
+// every field from the network's raw [-1, 1] output range to [min, max] (see Denormalize).
+type NetworkSpec struct {
+	Width        int         `json:"width" yaml:"width"`
+	Depth        int         `json:"depth" yaml:"depth"`
+	Normalize    bool        `json:"normalize" yaml:"normalize"`
+	Fourier      int         `json:"fourier" yaml:"fourier"`
+	FourierScale float64     `json:"fourier_scale" yaml:"fourier_scale"`
+	Output       *OutputSpec `json:"output" yaml:"output"`
+}
+
+// OutputSpec is the expected range of the solution fields.
+type OutputSpec struct {
+	Min float64 `json:"min" yaml:"min"`
+	Max float64 `json:"max" yaml:"max"`
+}
+
+// SamplerSpec selects the training points.  Kind "grid" (the default) places Resolution
+// points along each input dimension.  Kind "random" draws Points uniform random points from the
+// domain using Seed, plus the boundary points of the Resolution grid so that conditions on the
+// domain boundary have points to apply at.  Resolution defaults to 6 and Points to
+// Resolution^dims.
+type SamplerSpec struct {
+	Kind       string `json:"kind" yaml:"kind"`
+	Resolution int    `json:"resolution" yaml:"resolution"`
+	Points     int    `json:"points" yaml:"points"`
+	Seed       int64  `json:"seed" yaml:"seed"`
+}
+
+// ReadProblemSpec reads a problem definition from a .yaml, .yml or .json file.
+func ReadProblemSpec(path string) (*ProblemSpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &ProblemSpec{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(spec)
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, spec)
+	default:
+		return nil, fmt.Errorf("unsupported problem file format '%v'", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	if spec.Name == "" {
+		spec.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return spec, nil
+}
+
+// LoadProblem reads a problem definition file and returns it as a Case.  The definition is fully
+// checked (e.g. all expressions are parsed) before LoadProblem returns.
+func LoadProblem(path string) (Case, error) {
+	spec, err := ReadProblemSpec(path)
+	if err != nil {
+		return Case{}, err
+	}
+	if _, err := spec.Build(Config{}); err != nil {
+		return Case{}, fmt.Errorf("%v: %v", path, err)
+	}
+	build := func(cfg Config) *Setup {
+		s, err := spec.Build(cfg)
+		if err != nil {
+			panic(err) // unreachable - the spec was already built successfully above
+		}
+		return s
+	}
+	return Case{Name: spec.Name, Desc: spec.Description, Build: build}, nil
+}
+
+// Build creates the problem's network, cost function and training points.  Nonzero cfg values
+// override the spec's network and sampler settings.
+func (spec *ProblemSpec) Build(cfg Config) (*Setup, error) {
+	if len(spec.Inputs) == 0 {
+		return nil, fmt.Errorf("problem has no inputs")
+	} else if len(spec.Fields) == 0 {
+		return nil, fmt.Errorf("problem has no fields")
+	} else if len(spec.Equations) == 0 && len(spec.Conditions) == 0 {
+		return nil, fmt.Errorf("problem has no equations or conditions")
+	}
+
+	var net Network
+	syms := map[string]Func{}
+	define := func(name string, f Func) error {
+		if name == "" {
+			return fmt.Errorf("missing name")
+		} else if _, ok := syms[name]; ok {
+			return fmt.Errorf("duplicate name '%v'", name)
+		}
+		syms[name] = f
+		return nil
+	}
+
+	var inputs []*Neuron
+	var normalized []Func
+	var lo, hi []float64
+	hasTime := false
+	for _, in := range spec.Inputs {
+		if in.Min > in.Max {
+			return nil, fmt.Errorf("input %v: min %v > max %v", in.Name, in.Min, in.Max)
+		}
+		newInput := net.NewInput
+		if in.Time {
+			if hasTime {
+				return nil, fmt.Errorf("more than one time input")
+			}
+			newInput, hasTime = net.NewTimeInput, true
+		}
+		neuron, v := newInput()
+		if err := define(in.Name, v); err != nil {
+			return nil, fmt.Errorf("input: %v", err)
+		}
+		net.SetVarName(v, in.Name)
+		if spec.Network.Normalize {
+			neuron.Normalize(in.Min, in.Max)
+		}
+		inputs = append(inputs, neuron)
+		normalized = append(normalized, Normalize(v, in.Min, in.Max))
+		lo, hi = append(lo, in.Min), append(hi, in.Max)
+	}
+	if out := spec.Network.Output; out != nil && !(out.Min < out.Max) {
+		return nil, fmt.Errorf("network: output min %v >= max %v", out.Min, out.Max)
+	}
+	if spec.Network.Fourier < 0 {
+		return nil, fmt.Errorf("network: negative number of Fourier features %v", spec.Network.Fourier)
+	} else if spec.Network.Fourier > 0 {
+		sigma := spec.Network.FourierScale
+		if sigma == 0 {
+			sigma = 1
+		}
+		freqs := RandomFrequencies(rand.New(rand.NewSource(1)), spec.Network.Fourier, len(normalized), sigma)
+		inputs = append(inputs, net.NewFourierInputs(normalized, freqs)...)
+	}
+
+	// like the built-in problems, add a dummy input with a constant value
+	dummyin, dummy := net.NewInput()
+	net.SetVarName(dummy, "dummy")
+	inputs = append(inputs, dummyin)
+	dummyv := 1.0
+	lo, hi = append(lo, dummyv), append(hi, dummyv)
+
+	width := orDefault(cfg.Width, orDefault(spec.Network.Width, 3))
+	depth := orDefault(cfg.Depth, orDefault(spec.Network.Depth, 1))
+	hidden := net.NewLayers(inputs, width, depth)
+
+	var fields []NamedFunc
+	for _, name := range spec.Fields {
+		if _, ok := syms[name]; ok {
+			return nil, fmt.Errorf("field: duplicate name '%v'", name)
+		}
+		var u Func = net.NewField(name).PullFrom(hidden...)
+		if out := spec.Network.Output; out != nil {
+			u = Denormalize(u, out.Min, out.Max)
+		}
+		if err := define(name, u); err != nil {
+			return nil, fmt.Errorf("field: %v", err)
+		}
+		fields = append(fields, NamedFunc{name, u})
+	}
+	for name, val := range spec.Coefficients {
+		if err := define(name, Constant(val)); err != nil {
+			return nil, fmt.Errorf("coefficient: %v", err)
+		}
+	}
+	for _, ps := range spec.Params {
+		p := net.NewParam(ps.Name, ps.Init)
+		if ps.Min < ps.Max {
+			if !(ps.Min < ps.Init && ps.Init < ps.Max) {
+				return nil, fmt.Errorf("param %v: init %v outside bounds (%v, %v)", ps.Name, ps.Init, ps.Min, ps.Max)
+			}
+			p.SetBounds(ps.Min, ps.Max)
+		}
+		if err := define(ps.Name, p); err != nil {
+			return nil, fmt.Errorf("param: %v", err)
+		}
+	}
+
+	var sys System
+	for i, eq := range spec.Equations {
+		name := eq.Name
+		if name == "" {
+			name = fmt.Sprintf("equation %v", i+1)
+		}
+		residual, err := ParseExpr(eq.Residual, syms)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+		sys.Add(name, residual, defaultWeight(eq.Weight))
+	}
+	for i, c := range spec.Conditions {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("condition %v", i+1)
+		}
+		residual, err := ParseExpr(c.Residual, syms)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+		where, err := ParsePredicate(c.Where, syms)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+		sys.AddCondition(Condition{Name: name, Where: where, Residual: residual}, defaultWeight(c.Weight))
+	}
+	net.CostFunc = sys.Cost()
+
+	var exact []NamedFunc
+	for _, name := range spec.Fields {
+		src, ok := spec.Exact[name]
+		if !ok {
+			continue
+		}
+		f, err := ParseExpr(src, syms)
+		if err != nil {
+			return nil, fmt.Errorf("exact %v: %v", name, err)
+		}
+		exact = append(exact, NamedFunc{name, f})
+	}
+	if len(exact) != len(spec.Exact) {
+		return nil, fmt.Errorf("exact solutions given for unknown fields")
+	}
+
+	if spec.Optimizer != "" {
+		newMethod, ok := optimizers[spec.Optimizer]
+		if !ok {
+			return nil, fmt.Errorf("unknown optimizer '%v'", spec.Optimizer)
+		}
+		net.Method = newMethod()
+	}
+
+	pts, err := spec.Sampler.sample(lo, hi, cfg.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	net.TrainData = pts
+
+	s := &Setup{Net: &net, Fields: fields, Exact: exact, Lo: lo, Hi: hi, EvalRes: 21}
+	if len(spec.Params) > 0 {
+		s.Report = func() {
+			for _, ps := range spec.Params {
+				fmt.Printf("Fitted %v = %v\n", ps.Name, net.ParamValues()[ps.Name])
+			}
+		}
+	}
+	return s, nil
+}
+
+func defaultWeight(w float64) float64 {
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// sample returns the training points for the domain [lo, hi].  A nonzero res overrides the
+// sampler's resolution.
+func (s SamplerSpec) sample(lo, hi []float64, res int) ([][]float64, error) {
+	res = orDefault(res, orDefault(s.Resolution, 6))
+	ns := make([]int, len(lo))
+	for i := range ns {
+		ns[i] = res
+		if lo[i] == hi[i] {
+			ns[i] = 1
+		}
+	}
+	grid := Grid(lo, hi, ns...)
+
+	switch s.Kind {
+	case "", "grid":
+		return grid, nil
+	case "random":
+	default:
+		return nil, fmt.Errorf("unknown sampler '%v'", s.Kind)
+	}
+
+	rng := rand.New(rand.NewSource(s.Seed))
+	npoints := orDefault(s.Points, len(grid))
+	var pts [][]float64
+	for i := 0; i < npoints; i++ {
+		pt := make([]float64, len(lo))
+		for j := range pt {
+			pt[j] = lo[j] + rng.Float64()*(hi[j]-lo[j])
+		}
+		pts = append(pts, pt)
+	}
+	for _, pt := range grid {
+		for j := range pt {
+			if lo[j] != hi[j] && (pt[j] == lo[j] || pt[j] == hi[j]) {
+				pts = append(pts, pt)
+				break
+			}
+		}
+	}
+	return pts, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProblemExamples(t *testing.T) {
+	paths, err := filepath.Glob("examples/*")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example problem files found (err=%v)", err)
+	}
+	for _, path := range paths {
+		c, err := LoadProblem(path)
+		if err != nil {
+			t.Errorf("%v: %v", path, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := cmdCheck(c.Build(Config{Resolution: 2}), &buf); err != nil {
+			t.Errorf("%v: %v\n%v", path, err, buf.String())
+		}
+	}
+}
+
+func TestProblemSpec(t *testing.T) {
+	const src = `
+name: bar
+inputs:
+  - {name: x, min: 0, max: 2}
+fields: [u]
+coefficients: {c: 3}
+params:
+  - {name: p, init: 1, min: 0, max: 4}
+equations:
+  - {residual: u - c}
+conditions:
+  - {name: left, where: x == 0, residual: u - p, weight: 10}
+sampler: {resolution: 3}
+exact: {u: c}
+`
+	path := filepath.Join(t.TempDir(), "bar.yaml")
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadProblem(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.Build(Config{})
+	net := s.Net
+	if c.Name != "bar" || len(s.Fields) != 1 || len(net.Params) != 1 {
+		t.Fatalf("got name %v with %v fields and %v params, want bar with 1 field and 1 param", c.Name, len(s.Fields), len(net.Params))
+	}
+	if len(s.Exact) != 1 || s.Exact[0].Name != "u" {
+		t.Errorf("got exact solutions %v, want one for u", s.Exact)
+	}
+	if len(net.TrainData) != 3 || net.TrainData[2][0] != 2 {
+		t.Errorf("got training points %v, want 3 points on [0,2]", net.TrainData)
+	}
+
+	// with all weights zero, u == 0 everywhere and p == 1 so the cost is sum (0-3)^2 + 10*(0-1)^2
+	net.initState()
+	x := make([]float64, len(net.trainable()))
+	x[len(x)-1] = net.Params[0].rawInit()
+	if got, want := net.Cost(x), 3*9.0+10; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got cost %v, want %v", got, want)
+	}
+
+	random := SamplerSpec{Kind: "random", Resolution: 3, Points: 5, Seed: 1}
+	pts, err := random.sample([]float64{0, 1}, []float64{2, 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 7 || pts[5][0] != 0 || pts[6][0] != 2 {
+		t.Errorf("want 5 random points plus the 2 boundary points, got %v", pts)
+	}
+}
+
+func TestProblemSpecErrors(t *testing.T) {
+	base := `{"inputs": [{"name": "x", "max": 1}], "fields": ["u"], "equations": [{"residual": "u"}]`
+	tests := map[string]string{
+		"":                                       "",
+		`, "fields": ["x"]`:                      "duplicate name",
+		`, "equations": [{"residual": "u + v"}]`: "unknown identifier 'v'",
+		`, "conditions": [{"where": "x", "residual": "u"}]`: "comparison",
+		`, "params": [{"name": "k", "init": 5, "max": 2}]`:  "outside bounds",
+		`, "exact": {"v": "1"}`:                             "unknown fields",
+		`, "optimizer": "newton"`:                           "unknown optimizer",
+		`, "sampler": {"kind": "sobol"}`:                    "unknown sampler",
+		`, "inputs": [{"name": "x", "max": -1}]`:            "min 0 > max -1",
+		`, "network": {"output": {"min": 2, "max": 1}}`:     "output min 2 >= max 1",
+		`, "equatons": []`:                                  "unknown field",
+		`, "network": {"fourier": -2}`:                      "negative number of Fourier features",
+	}
+	dir := t.TempDir()
+	for extra, want := range tests {
+		path := filepath.Join(dir, "p.json")
+		if err := ioutil.WriteFile(path, []byte(base+extra+"}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadProblem(path)
+		if want == "" {
+			if err != nil {
+				t.Errorf("base problem: %v", err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: got error %v, want error containing %q", extra, err, want)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Config holds the user-chosen settings that problems are built with.  Zero values select each
+// problem's defaults.
+type Config struct {
+	// Resolution is the number of training (collocation) points along each input dimension.
+	Resolution int
+	// Width and Depth are the number of neurons per hidden layer and number of hidden layers.
+	Width, Depth int
+}
+
+func orDefault(val, def int) int {
+	if val == 0 {
+		return def
+	}
+	return val
+}
+
+// NamedFunc is a function (e.g. a solution field) with a name.
+type NamedFunc struct {
+	Name string
+	Func
+}
+
+// Setup is a fully defined problem ready for training.
+type Setup struct {
+	Net *Network
+	// Fields are the solution fields reported by evaluation and plotting - often transformed
+	// network outputs rather than the raw outputs.
+	Fields []NamedFunc
+	// Lo and Hi bound the domain of each input variable (in Net.Vars order) for evaluation grids.
+	// Dummy inputs have Lo == Hi.
+	Lo, Hi []float64
+	// EvalRes is the default number of evaluation points along each (non-dummy) dimension.
+	EvalRes int
+	// Exact holds known analytic solutions for (some of) the fields by field name.  They are used
+	// to report error norms after solving and evaluating.
+	Exact []NamedFunc
+	// Report, if non-nil, prints any extra results (e.g. fitted parameters) after solving.
+	Report func()
+}
+
+// EvalGrid returns a grid of evaluation points over the setup's domain with res points along
+// each input dimension (or EvalRes points if res is zero).
+func (s *Setup) EvalGrid(res int) [][]float64 {
+	res = orDefault(res, s.EvalRes)
+	ns := make([]int, len(s.Lo))
+	for i := range ns {
+		ns[i] = res
+		if s.Lo[i] == s.Hi[i] {
+			ns[i] = 1
+		}
+	}
+	return Grid(s.Lo, s.Hi, ns...)
+}
+
+// DomainVars returns the input variables that vary over the problem domain (i.e. excluding any
+// dummy inputs).
+func (s *Setup) DomainVars() []Variable {
+	var vars []Variable
+	for i, v := range s.Net.Vars {
+		if s.Lo[i] != s.Hi[i] {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// EvalTable evaluates every solution field at the given points.
+func (s *Setup) EvalTable(pts [][]float64) *Table {
+	t := &Table{}
+	for _, v := range s.Net.Vars {
+		t.Columns = append(t.Columns, s.Net.VarName(v))
+	}
+	var vals [][]float64
+	for _, f := range s.Fields {
+		t.Columns = append(t.Columns, f.Name)
+		vals = append(vals, s.Net.EvalBatch(f, pts))
+	}
+	for k, pt := range pts {
+		row := append([]float64{}, pt...)
+		for i := range s.Fields {
+			row = append(row, vals[i][k])
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// Case is a named problem that can be selected and solved from the command line.
+type Case struct {
+	Name  string
+	Desc  string
+	Build func(cfg Config) *Setup
+}
+
+var registry = map[string]Case{}
+
+// Register makes the problem available by name.
+func Register(c Case) {
+	if _, ok := registry[c.Name]; ok {
+		panic("duplicate problem " + c.Name)
+	}
+	registry[c.Name] = c
+}
+
+// Cases returns all registered problems sorted by name.
+func Cases() []Case {
+	var cases []Case
+	for _, c := range registry {
+		cases = append(cases, c)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases
+}
+
+func init() {
+	Register(Case{"1d", "fit the constant u(x) = 3 on [0,5]", prob1d})
+	Register(Case{"1d-discont", "steady 1D heat conduction -k*u'' = S with u(0) = u(1) = 0", prob1dDiscont})
+	Register(Case{"1d-ritz", "1D heat conduction -u'' = S with u(0) = u(1) = 0 by energy (Deep Ritz) minimization", probRitz1d})
+	Register(Case{"2d", "2D Poisson problem 2*laplace(u) = 10 on [0,5]x[0,5]", prob2d})
+	Register(Case{"stokes", "2D steady Stokes flow driven by a body force", probStokes})
+	Register(Case{"heat1d", "transient 1D heat equation with u(x,0) = sin(pi*x)", probHeat1d})
+	Register(Case{"inverse1d", "infer the conductivity of a 1D heat problem from measurements", probInverse1d})
+}
+
+// NewLayers builds depth fully connected hidden layers of width (tanh) neurons fed by the inputs
+// and returns the last layer.  If depth is zero, inputs is returned unchanged.
+func (n *Network) NewLayers(inputs []*Neuron, width, depth int) []*Neuron {
+	layer := inputs
+	for d := 0; d < depth; d++ {
+		var next []*Neuron
+		for i := 0; i < width; i++ {
+			next = append(next, n.NewNeuron().PullFrom(layer...))
+		}
+		layer = next
+	}
+	return layer
+}
+
+func prob2d(cfg Config) *Setup {
+	var net Network
+	in1, var1 := net.NewInput()
+	in2, var2 := net.NewInput()
+	net.SetVarName(var1, "x")
+	net.SetVarName(var2, "y")
+	// scale the [0, 5] coordinates to [-1, 1] so the input neurons don't saturate
+	in1.Normalize(0, 5)
+	in2.Normalize(0, 5)
+	hidden := net.NewLayers([]*Neuron{in1, in2}, orDefault(cfg.Width, 3), orDefault(cfg.Depth, 0))
+	out1 := net.NewField("u").PullFrom(hidden...)
+
+	// a PDE would be defined like follows
+	u, x, y := out1, var1, var2
+	forcingFunc := Constant(10)
+	diffusionCoeff := Constant(2)
+	residual := Sum{Mult{Laplace(u, x, y), diffusionCoeff}, Negative(forcingFunc)}
+
+	// we can then use the (squared) residual as a cost function to update the weights using a
+	// backpropogation algorithm.
+	net.CostFunc = &Pow{residual, Constant(2)}
+
+	// build training data (input variable combos)
+	res := orDefault(cfg.Resolution, 50)
+	net.TrainData = Grid([]float64{0, 0}, []float64{5, 5}, res, res)
+
+	return &Setup{
+		Net:     &net,
+		Fields:  []NamedFunc{{"u", u}},
+		Lo:      []float64{0, 0},
+		Hi:      []float64{5, 5},
+		EvalRes: 50,
+	}
+}
+
+func probStokes(cfg Config) *Setup {
+	var net Network
+	in1, var1 := net.NewInput()
+	in2, var2 := net.NewInput()
+	dummyin, dummy := net.NewInput()
+	net.SetVarName(var1, "x")
+	net.SetVarName(var2, "y")
+	net.SetVarName(dummy, "dummy")
+
+	// hidden layers shared by all the output fields
+	hidden := net.NewLayers([]*Neuron{in1, in2, dummyin}, orDefault(cfg.Width, 3), orDefault(cfg.Depth, 1))
+
+	u := net.NewField("u").PullFrom(hidden...)
+	v := net.NewField("v").PullFrom(hidden...)
+	p := net.NewField("p").PullFrom(hidden...)
+	x, y := var1, var2
+
+	// steady Stokes flow with unit viscosity driven by a constant body force in x:
+	//     -laplace(u) + dp/dx = f
+	//     -laplace(v) + dp/dy = 0
+	//     du/dx + dv/dy = 0
+	force := Constant(1)
+	var sys System
+	sys.Add("momentum-x", Sum{Negative(Laplace(u, x, y)), p.Partial(x), Negative(force)}, 1)
+	sys.Add("momentum-y", Sum{Negative(Laplace(v, x, y)), p.Partial(y)}, 1)
+	sys.Add("continuity", Div([]Func{u, v}, x, y), 1)
+	net.CostFunc = sys.Cost()
+
+	dummyv := 1.0
+	res := orDefault(cfg.Resolution, 5)
+	net.TrainData = Grid([]float64{0, 0, dummyv}, []float64{0.8, 0.8, dummyv}, res, res, 1)
+
+	return &Setup{
+		Net:     &net,
+		Fields:  []NamedFunc{{"u", u}, {"v", v}, {"p", p}},
+		Lo:      []float64{0, 0, dummyv},
+		Hi:      []float64{1, 1, dummyv},
+		EvalRes: 11,
+	}
+}
+
+func probHeat1d(cfg Config) *Setup {
+	var net Network
+	in1, var1 := net.NewInput()
+	in2, var2 := net.NewTimeInput()
+	dummyin, dummy := net.NewInput()
+	net.SetVarName(var1, "x")
+	net.SetVarName(var2, "t")
+	net.SetVarName(dummy, "dummy")
+
+	hidden := net.NewLayers([]*Neuron{in1, in2, dummyin}, orDefault(cfg.Width, 3), orDefault(cfg.Depth, 1))
+	u := net.NewField("u").PullFrom(hidden...)
+	x, t := var1, var2
+
+	// du/dt = alpha*laplace(u) on x in [0,1] with u(x,0) = sin(pi*x) and u(0,t) = u(1,t) = 0
+	alpha := Constant(0.1)
+	var sys System
+	sys.Add("interior", HeatResidual(u, t, alpha, x), 1)
+	initial := Branch(func(xv []float64) Func { return Constant(math.Sin(math.Pi * xv[int(x)])) })
+	sys.AddCondition(InitialValue("initial-value", u, t, 0, initial), 100)
+	onBoundary := func(xv []float64) bool { return xv[int(x)] == 0 || xv[int(x)] == 1 }
+	sys.AddCondition(DirichletBC("walls", u, Constant(0), onBoundary), 100)
+	net.CostFunc = sys.Cost()
+
+	dummyv := 1.0
+	res := orDefault(cfg.Resolution, 6)
+	net.TrainData = Grid([]float64{0, 0, dummyv}, []float64{1, 1, dummyv}, res, res, 1)
+
+	return &Setup{
+		Net:    &net,
+		Fields: []NamedFunc{{"u", u}},
+		// u = exp(-alpha*pi^2*t)*sin(pi*x)
+		Exact:   []NamedFunc{{"u", Mult{Exp(Mult{Constant(-math.Pi * math.Pi), alpha, t}), Sin{Mult{Constant(math.Pi), x}}}}},
+		Lo:      []float64{0, 0, dummyv},
+		Hi:      []float64{1, 1, dummyv},
+		EvalRes: 11,
+	}
+}
+
+func prob1d(cfg Config) *Setup {
+	var net Network
+	in1, var1 := net.NewInput()
+	// This is a dummy input and variable to enable the network to output nonzero values when all
+	// inputs are zero.
+	dummyin, dummy := net.NewInput()
+	net.SetVarName(var1, "x")
+	net.SetVarName(dummy, "dummy")
+
+	hidden := net.NewLayers([]*Neuron{in1, dummyin}, orDefault(cfg.Width, 3), orDefault(cfg.Depth, 0))
+	out1 := net.NewField("u").PullFrom(hidden...)
+
+	// we want to approximate u(x) = 3 so error=(u-3)^2
+	u := out1
+	residual := &Pow{Sum{u, Constant(-3)}, Constant(2)}
+	net.CostFunc = residual
+
+	// build training data (input variable combos) - the dummy input value corresponding to our
+	// dummy variable is always 1.
+	dummyv := 1.0
+	net.TrainData = Grid([]float64{0, dummyv}, []float64{5, dummyv}, orDefault(cfg.Resolution, 50), 1)
+
+	return &Setup{
+		Net:     &net,
+		Fields:  []NamedFunc{{"u", u}},
+		Exact:   []NamedFunc{{"u", Constant(3)}},
+		Lo:      []float64{0, dummyv},
+		Hi:      []float64{5, dummyv},
+		EvalRes: 51,
+	}
+}
+
+func probInverse1d(cfg Config) *Setup {
+	var net Network
+	in1, var1 := net.NewInput()
+	dummyin, dummy := net.NewInput()
+	net.SetVarName(var1, "x")
+	net.SetVarName(dummy, "dummy")
+
+	hidden := net.NewLayers([]*Neuron{in1, dummyin}, orDefault(cfg.Width, 3), orDefault(cfg.Depth, 1))
+	out1 := net.NewOutput().PullFrom(hidden...)
+
+	x := var1
+	u := HardDirichlet(out1, Constant(0), BoxDistance([]float64{0}, []float64{1}, x))
+
+	// infer the conductivity from measurements of u given a known heat source
+	k := net.NewParam("k", 1).SetBounds(0.1, 10)
+	heatSource := Constant(70)
+	residual := Sum{Mult{k, Laplace(u, x)}, heatSource}
+	measured := net.NewTarget()
+	net.SetVarName(measured, "u_measured")
+	net.CostFunc = Sum{&Pow{residual, Constant(2)}, Mult{Constant(100), DataMisfit(u, measured, MSE)}}
+
+	// synthetic measurements from the exact solution u = S/(2k)*x*(1-x) with k = 2
+	trueK := 2.0
+	dummyv := 1.0
+	for _, pt := range Grid([]float64{0.05, dummyv}, []float64{0.95, dummyv}, orDefault(cfg.Resolution, 10), 1) {
+		xv := pt[0]
+		net.AddSample(pt, []float64{70 / (2 * trueK) * xv * (1 - xv)})
+	}
+
+	return &Setup{
+		Net:     &net,
+		Fields:  []NamedFunc{{"u", u}},
+		Exact:   []NamedFunc{{"u", Mult{Constant(70 / (2 * trueK)), x, Sum{Constant(1), Negative(x)}}}},
+		Lo:      []float64{0, dummyv},
+		Hi:      []float64{1, dummyv},
+		EvalRes: 101,
+		Report: func() {
+			fmt.Printf("Fitted k = %v (true k = %v)\n", net.ParamValues()["k"], trueK)
+		},
+	}
+}
+
+func prob1dDiscont(cfg Config) *Setup {
+	var net Network
+	in1, var1 := net.NewInput()
+	// This is a dummy input and variable to enable the network to output nonzero values when all
+	// inputs are zero.
+	dummyin, dummy := net.NewInput()
+	net.SetVarName(var1, "x")
+	net.SetVarName(dummy, "dummy")
+
+	// hidden layer
+	hidden := net.NewLayers([]*Neuron{in1, dummyin}, orDefault(cfg.Width, 3), orDefault(cfg.Depth, 1))
+	out1 := net.NewOutput().PullFrom(hidden...)
+
+	// convenient vars/names for building our PDE and BCs
+	x := var1
+
+	// enforce the boundary conditions u(0) = uLeft and u(1) = uRight exactly by transforming the
+	// network output - this way no boundary penalty terms are needed in the cost function.
+	uLeft, uRight := 0.0, 0.0
+	u := HardDirichlet(out1, LinearLift(x, 0, 1, uLeft, uRight), BoxDistance([]float64{0}, []float64{1}, x))
+
+	k := Branch(func(xv []float64) Func {
+		if xv[int(x)] < 0.5 {
+			return Constant(1)
+		}
+		return Constant(1)
+	})
+	heatSource := Constant(70)
+	// define our PDE: -k*laplace(u)=S --> residual R=k*laplace(u)+S
+	residual := Sum{Mult{k, Laplace(u, x)}, heatSource}
+
+	net.CostFunc = &Pow{residual, Constant(2)}
+
+	// build training data (input variable combos)
+	dummyv := 1.0 // dummy input value corresponding to our dummy variable
+	net.TrainData = Grid([]float64{0.01, dummyv}, []float64{0.91, dummyv}, orDefault(cfg.Resolution, 10), 1)
+
+	return &Setup{
+		Net:    &net,
+		Fields: []NamedFunc{{"u", u}},
+		// with k = 1 everywhere the solution is u = S/(2k)*x*(1-x)
+		Exact:   []NamedFunc{{"u", Mult{Constant(35), x, Sum{Constant(1), Negative(x)}}}},
+		Lo:      []float64{0, dummyv},
+		Hi:      []float64{1, dummyv},
+		EvalRes: 101,
+	}
+}
+
+// probRitz1d solves the same problem as "1d-discont" (with k = 1) by minimizing the energy
+// functional instead of the strong form residual.
+func probRitz1d(cfg Config) *Setup {
+	var net Network
+	in1, var1 := net.NewInput()
+	dummyin, dummy := net.NewInput()
+	net.SetVarName(var1, "x")
+	net.SetVarName(dummy, "dummy")
+
+	hidden := net.NewLayers([]*Neuron{in1, dummyin}, orDefault(cfg.Width, 3), orDefault(cfg.Depth, 1))
+	out1 := net.NewOutput().PullFrom(hidden...)
+
+	x := var1
+	u := HardDirichlet(out1, Constant(0), BoxDistance([]float64{0}, []float64{1}, x))
+	net.CostFunc = EnergyDensity(u, Constant(1), Constant(70), x)
+
+	dummyv := 1.0
+	lo, hi := []float64{0, dummyv}, []float64{1, dummyv}
+	net.SetQuadrature(lo, hi, orDefault(cfg.Resolution, 10))
+
+	return &Setup{
+		Net:     &net,
+		Fields:  []NamedFunc{{"u", u}},
+		Exact:   []NamedFunc{{"u", Mult{Constant(35), x, Sum{Constant(1), Negative(x)}}}},
+		Lo:      lo,
+		Hi:      hi,
+		EvalRes: 101,
+	}
+}
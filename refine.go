@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Refiner adaptively adds collocation points to a network's training data where the residual
+// is large - e.g. near steep gradients or discontinuities.
+type Refiner struct {
+	// Residual is the function whose magnitude selects new points (default the network's
+	// CostFunc).
+	Residual Func
+	// Candidates is a dense set of candidate points (in n.Vars order) that new training points
+	// are chosen from.  Candidates already in the training data are never added twice.
+	Candidates [][]float64
+	// Add is the number of points added per refinement.
+	Add int
+	// Rand selects the sampling strategy.  If nil, the Add candidates with the largest residual
+	// magnitudes are added (residual-based adaptive refinement, RAR).  Otherwise candidates are
+	// drawn randomly (without replacement) with probability proportional to their residual
+	// magnitude (importance sampling).
+	Rand *rand.Rand
+	// Out, if non-nil, receives a summary line for every refinement.
+	Out io.Writer
+}
+
+// Refine evaluates the residual at every candidate point using the network's current weights,
+// appends the selected points to n.TrainData and returns them.  Quadrature weighted training
+// points (see PointWeights) are never refined.
+func (n *Network) Refine(r *Refiner) [][]float64 {
+	if n.PointWeights != nil {
+		return nil
+	}
+	residual := r.Residual
+	if residual == nil {
+		residual = n.CostFunc
+	}
+
+	have := map[string]bool{}
+	for _, pt := range n.TrainData {
+		have[fmt.Sprint(pt)] = true
+	}
+
+	type candidate struct {
+		pt  []float64
+		mag float64
+	}
+	var cands []candidate
+	maxMag := 0.0
+	for k, val := range n.EvalBatch(residual, r.Candidates) {
+		pt := r.Candidates[k]
+		if have[fmt.Sprint(pt)] {
+			continue
+		}
+		mag := math.Abs(val)
+		if math.IsNaN(mag) {
+			continue
+		}
+		cands = append(cands, candidate{pt, mag})
+		maxMag = math.Max(maxMag, mag)
+	}
+
+	var added [][]float64
+	if r.Rand == nil {
+		sort.SliceStable(cands, func(i, j int) bool { return cands[i].mag > cands[j].mag })
+		for i := 0; i < r.Add && i < len(cands); i++ {
+			added = append(added, cands[i].pt)
+		}
+	} else {
+		tot := 0.0
+		for _, c := range cands {
+			tot += c.mag
+		}
+		for len(added) < r.Add && len(cands) > 0 {
+			// fall back to uniform sampling if every remaining residual is zero
+			i := r.Rand.Intn(len(cands))
+			if tot > 0 {
+				target, cum := r.Rand.Float64()*tot, 0.0
+				for i = range cands {
+					if cum += cands[i].mag; cum > target {
+						break
+					}
+				}
+			}
+			added = append(added, cands[i].pt)
+			tot -= cands[i].mag
+			cands = append(cands[:i], cands[i+1:]...)
+		}
+	}
+
+	for _, pt := range added {
+		n.TrainData = append(n.TrainData, append([]float64{}, pt...))
+	}
+	if r.Out != nil {
+		fmt.Fprintf(r.Out, "Refine: added %v points (max residual %v), %v training points total\n", len(added), maxMag, len(n.TrainData))
+	}
+	return added
+}
+
+// TrainAdaptive trains the network and then alternates refining the training data with r and
+// retraining from the current weights for the given number of refinements.  It stops and returns
+// NonFinite if any training run fails.
+func (n *Network) TrainAdaptive(r *Refiner, refinements int) error {
+	n.Train()
+	if n.NonFinite != nil {
+		return n.NonFinite
+	}
+	for i := 0; i < refinements; i++ {
+		if len(n.Refine(r)) == 0 {
+			return nil
+		}
+		n.Train()
+		if n.NonFinite != nil {
+			return n.NonFinite
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRefine(t *testing.T) {
+	var net Network
+	_, x := net.NewInput()
+	net.initState()
+	net.TrainData = [][]float64{{0.5}}
+	cands := Grid([]float64{0}, []float64{1}, 11)
+
+	// the residual grows with x so RAR must pick the right end first
+	r := &Refiner{Residual: Sum{x, Constant(-0.05)}, Candidates: cands, Add: 2}
+	added := net.Refine(r)
+	if len(added) != 2 || added[0][0] != 1 || added[1][0] != 0.9 {
+		t.Errorf("got %v, want [[1] [0.9]]", added)
+	}
+	if len(net.TrainData) != 3 {
+		t.Errorf("got %v training points, want 3", len(net.TrainData))
+	}
+
+	// importance sampling never picks existing points or zero residual points
+	r = &Refiner{Residual: Mult{x, Sum{x, Constant(-0.2)}}, Candidates: cands, Add: 4, Rand: rand.New(rand.NewSource(1))}
+	added = net.Refine(r)
+	if len(added) != 4 {
+		t.Fatalf("got %v points, want 4", len(added))
+	}
+	seen := map[float64]bool{}
+	for _, pt := range added {
+		if v := pt[0]; v == 0 || v == 0.2 || v == 0.5 || v == 0.9 || v == 1 || seen[v] {
+			t.Errorf("unexpected point %v in %v", v, added)
+		}
+		seen[pt[0]] = true
+	}
+
+	// stop adding once the candidates run out
+	r = &Refiner{Residual: x, Candidates: cands, Add: 100}
+	if added := net.Refine(r); len(added) != 11-7 {
+		t.Errorf("got %v remaining points, want 4", len(added))
+	}
+}
+
+func TestTrainAdaptive(t *testing.T) {
+	s := registry["1d"].Build(Config{Resolution: 2})
+	if err := s.Net.TrainAdaptive(&Refiner{Candidates: s.EvalGrid(6), Add: 2}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.Net.TrainData); got != 6 {
+		t.Errorf("got %v training points, want 6", got)
+	}
+	if e := s.Errors(6); e[0].RelLInf > 1e-4 {
+		t.Errorf("errors too large: %+v", e[0])
+	}
+}
+
+func TestRefineIgnoresTargets(t *testing.T) {
+	var net Network
+	_, x := net.NewInput()
+	target := net.NewTarget()
+	net.initState()
+	net.AddSample([]float64{0.5}, []float64{100})
+	net.setPoint(0)
+
+	// candidates have no observations so the stale target from the last training point must
+	// not make the misfit dominate far from it
+	r := &Refiner{Residual: Sum{x, DataMisfit(x, target, MSE)}, Candidates: Grid([]float64{0}, []float64{1}, 11), Add: 1}
+	if added := net.Refine(r); len(added) != 1 || added[0][0] != 1 {
+		t.Errorf("got %v, want [[1]]", added)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Regularizer is a weighted penalty added to the network's cost (see Network.Regularizers) -
+// e.g. to keep the weights bounded.  If PerPoint is true, Func is summed over the training
+// points like CostFunc (weighted by PointWeights), otherwise it is evaluated once per cost
+// evaluation.
+type Regularizer struct {
+	Name     string
+	Func     Func
+	Weight   float64
+	PerPoint bool
+	// partials of Func w.r.t. each trainable variable - built on first use
+	partials []Func
+}
+
+// AddRegularizer attaches a penalty to the network's cost and returns it.
+func (n *Network) AddRegularizer(name string, f Func, weight float64, perPoint bool) *Regularizer {
+	r := &Regularizer{Name: name, Func: f, Weight: weight, PerPoint: perPoint}
+	n.Regularizers = append(n.Regularizers, r)
+	return r
+}
+
+// AddL2 adds weight*sum(w^2) over the network weights (weight decay).
+func (n *Network) AddL2(weight float64) *Regularizer {
+	return n.AddRegularizer("L2", n.weightPenalty(0, 1), weight, false)
+}
+
+// AddL1 adds weight*sum(|w|) over the network weights which drives unneeded weights to zero.
+func (n *Network) AddL1(weight float64) *Regularizer {
+	return n.AddRegularizer("L1", n.weightPenalty(1, 0), weight, false)
+}
+
+// AddElasticNet adds weight*(ratio*sum(|w|) + (1-ratio)/2*sum(w^2)) over the network weights -
+// i.e. a blend of L1 (ratio 1) and L2 (ratio 0) penalties.
+func (n *Network) AddElasticNet(weight, ratio float64) *Regularizer {
+	return n.AddRegularizer("elastic-net", n.weightPenalty(ratio, (1-ratio)/2), weight, false)
+}
+
+// AddGradientPenalty adds a Sobolev-type penalty of weight*|grad u|^2 summed over the training
+// points, where the gradient is taken w.r.t. vars.  This penalizes spurious oscillations of u
+// between training points.
+func (n *Network) AddGradientPenalty(weight float64, u Func, vars ...Variable) *Regularizer {
+	var sum Sum
+	for _, v := range vars {
+		sum = append(sum, &Pow{u.Partial(v), Constant(2)})
+	}
+	return n.AddRegularizer("gradient", sum, weight, true)
+}
+
+func (n *Network) weightPenalty(l1, l2 float64) Func {
+	var sum Sum
+	for _, w := range n.Weights {
+		if l1 != 0 {
+			sum = append(sum, Mult{Constant(l1), Abs(w)})
+		}
+		if l2 != 0 {
+			sum = append(sum, Mult{Constant(l2), &Pow{w, Constant(2)}})
+		}
+	}
+	return sum
+}
+
+// regularization returns the weighted sum of all regularizers at the network's current weights.
+func (n *Network) regularization() float64 {
+	tot := 0.0
+	for _, r := range n.Regularizers {
+		tot += r.Weight * n.regValue(r)
+	}
+	return tot
+}
+
+// regValue returns the unweighted value of r at the network's current weights.
+func (n *Network) regValue(r *Regularizer) float64 {
+	if !r.PerPoint {
+		return r.Func.Val(n.state)
+	}
+	tot := 0.0
+	for i := range n.TrainData {
+		n.setPoint(i)
+		tot += n.pointWeight(i) * r.Func.Val(n.state)
+	}
+	return tot
+}
+
+// addRegGradient adds the gradient of all the weighted regularizers w.r.t. the trainable
+// variables vars to gradw.
+func (n *Network) addRegGradient(gradw []float64, vars []Variable) {
+	for _, r := range n.Regularizers {
+		if len(r.partials) != len(vars) {
+			r.partials = nil
+			for _, w := range vars {
+				r.partials = append(r.partials, r.Func.Partial(w).Simplify())
+			}
+		}
+		npts, weight := len(n.TrainData), r.Weight
+		if !r.PerPoint {
+			npts = 1
+		}
+		for k := 0; k < npts; k++ {
+			if r.PerPoint {
+				n.setPoint(k)
+				weight = r.Weight * n.pointWeight(k)
+			}
+			for i, p := range r.partials {
+				gradw[i] += weight * p.Val(n.state)
+			}
+		}
+	}
+}
+
+// ReportRegularizers writes the value, weight and weighted value of each regularizer at the
+// network's current weights.
+func (n *Network) ReportRegularizers(w io.Writer) {
+	if len(n.Regularizers) == 0 {
+		return
+	}
+	n.initState()
+	fmt.Fprintf(w, "Regularizers:\n")
+	for _, r := range n.Regularizers {
+		val := n.regValue(r)
+		fmt.Fprintf(w, "    %v: %v (weight %v, weighted %v)\n", r.Name, val, r.Weight, r.Weight*val)
+	}
+}
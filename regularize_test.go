@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRegularizers(t *testing.T) {
+	var net Network
+	in, x := net.NewInput()
+	hidden := net.NewLayers([]*Neuron{in}, 2, 1)
+	u := net.NewField("u").PullFrom(hidden...)
+	net.CostFunc = Constant(0)
+	net.TrainData = [][]float64{{-0.5}, {0.5}}
+
+	weights := make([]float64, len(net.Weights))
+	l1, l2 := 0.0, 0.0
+	for i := range weights {
+		weights[i] = 0.3*float64(i) - 0.65
+		l1 += math.Abs(weights[i])
+		l2 += weights[i] * weights[i]
+	}
+	net.initState()
+
+	tests := []struct {
+		Name string
+		Add  func() *Regularizer
+		Want float64
+	}{
+		{"L2", func() *Regularizer { return net.AddL2(2) }, 2 * l2},
+		{"L1", func() *Regularizer { return net.AddL1(3) }, 3 * l1},
+		{"elastic-net", func() *Regularizer { return net.AddElasticNet(2, 0.25) }, 2 * (0.25*l1 + 0.75/2*l2)},
+		{"gradient", func() *Regularizer { return net.AddGradientPenalty(1, u, x) }, math.NaN()},
+	}
+	for _, test := range tests {
+		net.Regularizers = nil
+		test.Add()
+		cost := net.Cost(weights)
+		if !math.IsNaN(test.Want) && math.Abs(cost-test.Want) > 1e-10 {
+			t.Errorf("%v: want cost %v, got %v", test.Name, test.Want, cost)
+		}
+
+		if err := net.CheckCostGradient(weights); err != nil {
+			t.Errorf("%v: %v", test.Name, err)
+		}
+	}
+
+	// gradient penalty is summed over the training points
+	got := net.Cost(weights)
+	g := u.Partial(x)
+	want := 0.0
+	for _, pt := range net.TrainData {
+		want += math.Pow(net.EvalFunc(g, pt), 2)
+	}
+	if math.Abs(got-want) > 1e-10 {
+		t.Errorf("gradient penalty: want %v, got %v", want, got)
+	}
+
+	// and weighted by the points' quadrature weights
+	net.PointWeights = []float64{0.25, 2}
+	want = 0.0
+	for i, pt := range net.TrainData {
+		want += net.PointWeights[i] * math.Pow(net.EvalFunc(g, pt), 2)
+	}
+	if got := net.Cost(weights); math.Abs(got-want) > 1e-10 {
+		t.Errorf("weighted gradient penalty: want %v, got %v", want, got)
+	}
+	if err := net.CheckCostGradient(weights); err != nil {
+		t.Errorf("weighted gradient penalty: %v", err)
+	}
+	net.PointWeights = nil
+
+	var buf bytes.Buffer
+	net.AddL2(1)
+	net.ReportRegularizers(&buf)
+	if out := buf.String(); !strings.Contains(out, "gradient: ") || !strings.Contains(out, "L2: ") {
+		t.Errorf("unexpected report:\n%v", out)
+	}
+}
+
+func TestL2ShrinksWeights(t *testing.T) {
+	train := func(l2 float64) float64 {
+		var net Network
+		in, _ := net.NewInput()
+		u := net.NewField("u").PullFrom(in)
+		target := net.NewTarget()
+		net.CostFunc = DataMisfit(u, target, MSE)
+		net.AddSample([]float64{1}, []float64{4})
+		if l2 > 0 {
+			net.AddL2(l2)
+		}
+		net.Train()
+		return norm(net.trainableVals())
+	}
+	if plain, reg := train(0), train(1); reg >= plain {
+		t.Errorf("L2 regularized weight norm %v should be less than unregularized %v", reg, plain)
+	}
+}
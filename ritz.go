@@ -0,0 +1,65 @@
+package main
+
+import "math/rand"
+
+// EnergyDensity returns the Ritz energy density 1/2*k*|grad u|^2 - f*u w.r.t. vars.  Its
+// integral over the domain is minimized by the solution of the elliptic problem
+// -div(k*grad u) = f with Dirichlet boundary conditions (enforced e.g. with HardDirichlet).
+// Using it as a network's CostFunc with quadrature weighted training points (see
+// SetQuadrature and SetMonteCarlo) trains the network with the Deep Ritz method - which needs
+// only first derivatives of u unlike the strong form residual.
+func EnergyDensity(u, k, f Func, vars ...Variable) Func {
+	grad := Grad(u, vars...)
+	return Sum{Mult{Constant(0.5), k, Dot(grad, grad)}, Negative(Mult{f, u})}
+}
+
+// pointWeight returns the weight of the i'th training point's cost (see PointWeights).
+func (n *Network) pointWeight(i int) float64 {
+	if i < len(n.PointWeights) {
+		return n.PointWeights[i]
+	}
+	return 1
+}
+
+// SetQuadrature replaces the training points with a tensor product Gauss-Legendre rule with
+// npts points along each dimension of the box lo <= x <= hi (in n.Vars order) and sets
+// PointWeights so that the cost approximates the integral of CostFunc over the box.  Dimensions
+// with lo == hi (e.g. dummy inputs) are held fixed.
+func (n *Network) SetQuadrature(lo, hi []float64, npts int) {
+	var dims []int
+	var dlo, dhi []float64
+	for i := range lo {
+		if lo[i] != hi[i] {
+			dims, dlo, dhi = append(dims, i), append(dlo, lo[i]), append(dhi, hi[i])
+		}
+	}
+	pts, weights := gaussLegendreBox(dlo, dhi, npts)
+	n.TrainData, n.Targets, n.PointWeights = nil, nil, weights
+	for _, pt := range pts {
+		full := append([]float64{}, lo...)
+		for d, i := range dims {
+			full[i] = pt[d]
+		}
+		n.TrainData = append(n.TrainData, full)
+	}
+}
+
+// SetMonteCarlo is like SetQuadrature but uses npts uniformly random training points, each
+// weighted by the box volume divided by npts.
+func (n *Network) SetMonteCarlo(lo, hi []float64, npts int, rng *rand.Rand) {
+	vol := 1.0
+	for i := range lo {
+		if lo[i] != hi[i] {
+			vol *= hi[i] - lo[i]
+		}
+	}
+	n.TrainData, n.Targets, n.PointWeights = nil, nil, nil
+	for k := 0; k < npts; k++ {
+		pt := make([]float64, len(lo))
+		for i := range pt {
+			pt[i] = lo[i] + rng.Float64()*(hi[i]-lo[i])
+		}
+		n.TrainData = append(n.TrainData, pt)
+		n.PointWeights = append(n.PointWeights, vol/float64(npts))
+	}
+}
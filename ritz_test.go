@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEnergyDensity(t *testing.T) {
+	// u = x^2*y: 1/2*k*|grad u|^2 - f*u = 1/2*3*((2xy)^2 + x^4) - 5*x^2*y
+	u := Mult{&Pow{x, Constant(2)}, y}
+	e := EnergyDensity(u, Constant(3), Constant(5), x, y)
+	xv, yv := 0.5, 2.0
+	want := 1.5*(math.Pow(2*xv*yv, 2)+math.Pow(xv, 4)) - 5*xv*xv*yv
+	if got := e.Val([]float64{xv, yv}); math.Abs(got-want) > 1e-12 {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestSetQuadrature(t *testing.T) {
+	var net Network
+	_, xv := net.NewInput()
+	net.NewInput()
+	net.CostFunc = &Pow{xv, Constant(2)}
+	net.AddSample([]float64{7, 7}, []float64{1})
+	lo, hi := []float64{0, 1}, []float64{2, 1}
+
+	net.SetQuadrature(lo, hi, 3)
+	if len(net.TrainData) != 3 || net.Targets != nil || net.TrainData[0][1] != 1 {
+		t.Errorf("want 3 training points with the dummy input fixed at 1, got %v", net.TrainData)
+	}
+	if got := net.Cost(net.trainableVals()); math.Abs(got-8.0/3) > 1e-12 {
+		t.Errorf("quadrature: want integral 8/3, got %v", got)
+	}
+	var loss Loss
+	loss.Add("x^2", net.CostFunc, 1)
+	if got := loss.Stats(&net)[0].Value; math.Abs(got-8.0/3) > 1e-12 {
+		t.Errorf("loss stats: want integral 8/3, got %v", got)
+	}
+	if _, err := net.SplitValidation(0.3, rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("want error splitting quadrature points, got nil")
+	}
+
+	net.SetMonteCarlo(lo, hi, 5000, rand.New(rand.NewSource(1)))
+	if got := net.Cost(net.trainableVals()); math.Abs(got-8.0/3) > 0.1 {
+		t.Errorf("Monte Carlo: want integral near 8/3, got %v", got)
+	}
+}
+
+func TestRitzAccuracy(t *testing.T) {
+	s := registry["1d-ritz"].Build(Config{})
+	s.Net.Train()
+	for _, e := range s.Errors(0) {
+		if e.RelL2 > 1e-2 {
+			t.Errorf("%v: relative L2 error %v is too large", e.Name, e.RelL2)
+		}
+	}
+
+	// the Ritz solution must satisfy the strong form -u'' = 70 and match the network trained on
+	// the strong form residual ("1d-discont" has k = 1 everywhere)
+	x, u := s.Net.Vars[0], s.Fields[0].Func
+	for _, pt := range s.EvalGrid(11)[1:10] {
+		if got := s.Net.EvalFunc(Laplace(u, x), pt); math.Abs(got+70) > 0.05*70 {
+			t.Errorf("u'' at %v: want -70, got %v", pt, got)
+		}
+	}
+	strong := registry["1d-discont"].Build(Config{Resolution: 3})
+	strong.Net.Train()
+	strongU := GoFunc(func(pt []float64) float64 {
+		return strong.Net.EvalFunc(strong.Fields[0].Func, []float64{pt[int(x)], 1})
+	})
+	if e := s.Net.ErrorNorms(u, strongU, s.EvalGrid(21)); e.RelL2 > 1e-2 {
+		t.Errorf("Ritz and strong form solutions differ by a relative L2 error of %v", e.RelL2)
+	}
+}
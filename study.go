@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Study sweeps network sizes, training point counts and optimizers for a problem.  Every
+// combination of the listed settings is built and trained from scratch with Network.Train and its
+// accuracy (against the problem's exact solutions) and cost recorded.  An empty list selects the
+// problem's default for that setting.
+type Study struct {
+	Case        Case
+	Widths      []int
+	Depths      []int
+	Resolutions []int
+	// Optimizers are 'adiff solve -optimizer' method names.
+	Optimizers []string
+	// EvalRes is the evaluation grid resolution used for error norms (0 for the problem default).
+	EvalRes int
+	// Log, if non-nil, receives a progress line for every completed run.
+	Log io.Writer
+}
+
+// StudyResult holds the outcome of one training run of a study.
+type StudyResult struct {
+	Config    Config
+	Optimizer string
+	// Weights and Points are the number of trainable variables and training points.
+	Weights, Points int
+	Iterations      int
+	Loss            float64
+	Errors          []ErrorNorms
+	Time            time.Duration
+	// Err is the reason training failed (a *NonFiniteError) or nil.  The loss of a failed run is
+	// NaN.
+	Err error
+}
+
+func orDefaults(vals []int) []int {
+	if len(vals) == 0 {
+		return []int{0}
+	}
+	return vals
+}
+
+// Run trains every combination of the study's settings and returns the results in sweep order
+// (optimizers varying slowest and widths fastest).
+func (st *Study) Run() ([]StudyResult, error) {
+	opts := st.Optimizers
+	if len(opts) == 0 {
+		opts = []string{""}
+	}
+	for _, opt := range opts {
+		if _, ok := optimizers[opt]; opt != "" && !ok {
+			return nil, fmt.Errorf("unknown optimizer '%v'", opt)
+		}
+	}
+
+	var results []StudyResult
+	for _, opt := range opts {
+		for _, res := range orDefaults(st.Resolutions) {
+			for _, depth := range orDefaults(st.Depths) {
+				for _, width := range orDefaults(st.Widths) {
+					cfg := Config{Resolution: res, Width: width, Depth: depth}
+					r := st.run(cfg, opt)
+					if st.Log != nil {
+						fmt.Fprintf(st.Log, "width=%v depth=%v res=%v optimizer=%v: %v weights, %v points, loss %v in %v\n",
+							width, depth, res, opt, r.Weights, r.Points, r.Loss, r.Time)
+						if r.Err != nil {
+							fmt.Fprintf(st.Log, "    failed: %v\n", r.Err)
+						}
+					}
+					results = append(results, r)
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+func (st *Study) run(cfg Config, opt string) StudyResult {
+	s := st.Case.Build(cfg)
+	net := s.Net
+	if opt != "" {
+		net.Method = optimizers[opt]()
+	}
+
+	start := time.Now()
+	net.Train()
+	r := StudyResult{
+		Config:     cfg,
+		Optimizer:  opt,
+		Weights:    len(net.trainable()),
+		Points:     len(net.TrainData),
+		Iterations: len(net.LossHistory),
+		Time:       time.Since(start),
+	}
+	if net.NonFinite != nil {
+		r.Loss, r.Err = math.NaN(), net.NonFinite
+	} else {
+		r.Loss = net.Cost(net.trainableVals())
+	}
+	r.Errors = s.Errors(st.EvalRes)
+	return r
+}
+
+// StudyTable tabulates study results with one row per run.  Settings left at the problem
+// default are reported as 0 and failed is 1 for runs whose training failed.  Error columns are
+// named e.g. "L2_u" for field u.  The optimizer column is labeled with the method name
+// ("default" for the problem default) for text formats and holds the code from optimizerIndex in
+// npy/npz files.
+func StudyTable(results []StudyResult) *Table {
+	t := &Table{Columns: []string{"width", "depth", "res", "optimizer", "weights", "points", "iterations", "loss", "seconds", "failed"}}
+	if len(results) > 0 {
+		for _, e := range results[0].Errors {
+			for _, norm := range []string{"L2", "Linf", "H1", "relL2", "relLinf"} {
+				t.Columns = append(t.Columns, norm+"_"+e.Name)
+			}
+		}
+	}
+	for i, r := range results {
+		name := r.Optimizer
+		if name == "" {
+			name = "default"
+		}
+		t.setLabel(i, 3, name)
+		row := []float64{
+			float64(r.Config.Width), float64(r.Config.Depth), float64(r.Config.Resolution),
+			float64(optimizerIndex(r.Optimizer)), float64(r.Weights), float64(r.Points),
+			float64(r.Iterations), r.Loss, r.Time.Seconds(), 0,
+		}
+		if r.Err != nil {
+			row[9] = 1
+		}
+		for _, e := range r.Errors {
+			row = append(row, e.L2, e.LInf, e.H1, e.RelL2, e.RelLInf)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// optimizerIndex returns a numeric code for the named optimizer for binary study tables - 0 for
+// the problem default and otherwise the 1-based index of the name in sorted order: bfgs=1, cg=2,
+// gd=3, lbfgs=4 and nelder-mead=5.
+func optimizerIndex(name string) int {
+	if name == "" {
+		return 0
+	}
+	var names []string
+	for n := range optimizers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return sort.SearchStrings(names, name) + 1
+}
+
+// StudyPlot returns a log-log plot of the relative L2 error of each field against either the
+// number of trainable "weights" or training "points".  Runs that differ only in the x-axis setting
+// are connected as one line and failed runs are left out.
+func StudyPlot(results []StudyResult, xaxis string) (*Plot, error) {
+	if xaxis != "weights" && xaxis != "points" {
+		return nil, fmt.Errorf("unknown study x-axis '%v' (want weights or points)", xaxis)
+	}
+
+	p := &Plot{Title: "Convergence", XLabel: xaxis, YLabel: "relative L2 error", LogX: true, LogY: true}
+	lines := map[string]*Line{}
+	var names []string
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		x, group := float64(r.Weights), fmt.Sprintf("res=%v", r.Config.Resolution)
+		if xaxis == "points" {
+			x, group = float64(r.Points), fmt.Sprintf("width=%v depth=%v", r.Config.Width, r.Config.Depth)
+		}
+		if r.Optimizer != "" {
+			group += " " + r.Optimizer
+		}
+		for _, e := range r.Errors {
+			name := e.Name + " " + group
+			l, ok := lines[name]
+			if !ok {
+				l = &Line{Name: name}
+				lines[name] = l
+				names = append(names, name)
+			}
+			l.X = append(l.X, x)
+			l.Y = append(l.Y, e.RelL2)
+		}
+	}
+	for _, name := range names {
+		l := lines[name]
+		sort.Sort(byX(*l))
+		p.Lines = append(p.Lines, *l)
+	}
+	return p, nil
+}
+
+// byX sorts a line's points by increasing x.
+type byX Line
+
+func (l byX) Len() int           { return len(l.X) }
+func (l byX) Less(i, j int) bool { return l.X[i] < l.X[j] }
+func (l byX) Swap(i, j int) {
+	l.X[i], l.X[j] = l.X[j], l.X[i]
+	l.Y[i], l.Y[j] = l.Y[j], l.Y[i]
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStudy(t *testing.T) {
+	st := &Study{Case: registry["1d"], Widths: []int{1, 2}, Depths: []int{1}, Resolutions: []int{3, 4}, EvalRes: 5}
+	results, err := st.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("got %v results, want 4", len(results))
+	}
+	if r := results[1]; r.Config.Width != 2 || r.Config.Resolution != 3 || r.Points != 3 || len(r.Errors) != 1 {
+		t.Errorf("unexpected second result %+v", r)
+	}
+	if results[0].Weights >= results[1].Weights {
+		t.Errorf("want more weights for width 2 than 1, got %v and %v", results[1].Weights, results[0].Weights)
+	}
+
+	tbl := StudyTable(results)
+	if len(tbl.Rows) != 4 || tbl.Col("relL2_u") < 0 || tbl.Rows[3][tbl.Col("points")] != 4 {
+		t.Errorf("unexpected table columns %v with rows %v", tbl.Columns, tbl.Rows)
+	}
+
+	p, err := StudyPlot(results, "weights")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Lines) != 2 || len(p.Lines[0].X) != 2 || p.Lines[0].Name != "u res=3" {
+		t.Errorf("want 2 lines (one per resolution) with 2 points each, got %+v", p.Lines)
+	}
+	if _, err := StudyPlot(results, "time"); err == nil {
+		t.Errorf("want error for unknown x-axis, got nil")
+	}
+
+	st.Optimizers = []string{"newton"}
+	if _, err := st.Run(); err == nil {
+		t.Errorf("want error for unknown optimizer, got nil")
+	}
+}
+
+func TestCLIStudy(t *testing.T) {
+	dir := t.TempDir()
+	out, plot := filepath.Join(dir, "study.csv"), filepath.Join(dir, "study.svg")
+	args := []string{"study", "-widths", "1,2", "-depths", "1", "-resolutions", "3", "-optimizers", "bfgs,lbfgs", "-x", "points", "-out", out, "-plot", plot, "1d"}
+	if err := runCLI(args); err != nil {
+		t.Fatal(err)
+	}
+	tbl, err := ReadTable(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tbl.Rows) != 4 {
+		t.Errorf("got %v rows, want 4", len(tbl.Rows))
+	}
+	if col := tbl.Col("optimizer"); tbl.label(0, col) != "bfgs" || tbl.label(3, col) != "lbfgs" {
+		t.Errorf("want optimizer names in the csv, got %v", tbl.Labels[col])
+	}
+
+	npz := filepath.Join(dir, "study.npz")
+	if err := runCLI(append(append([]string{"study", "-out", npz}, args[1:9]...), "1d")); err != nil {
+		t.Fatal(err)
+	}
+	if tbl, err = ReadTable(npz); err != nil {
+		t.Fatal(err)
+	} else if got := tbl.Rows[2][tbl.Col("optimizer")]; got != float64(optimizerIndex("lbfgs")) {
+		t.Errorf("want npz optimizer code %v for lbfgs, got %v", optimizerIndex("lbfgs"), got)
+	}
+	if err := runCLI([]string{"study", "-widths", "1,x", "1d"}); err == nil {
+		t.Errorf("want error for invalid width list, got nil")
+	}
+}
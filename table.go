@@ -0,0 +1,559 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Table is a set of rows of values with named columns used for reading and writing training
+// data, evaluation grids and solutions.
+type Table struct {
+	Columns []string
+	Rows    [][]float64
+	// Labels holds text values (e.g. method names) for some columns by column index.  Text formats
+	// read and write the labels in place of the numeric values while npy and npz files only store
+	// the numeric values.  Empty labels fall back to the numeric value.
+	Labels map[int][]string
+}
+
+// label returns the text label of row i in column j or "" if it has none.
+func (t *Table) label(i, j int) string {
+	if i < len(t.Labels[j]) {
+		return t.Labels[j][i]
+	}
+	return ""
+}
+
+// setLabel sets the text label of row i in column j.
+func (t *Table) setLabel(i, j int, s string) {
+	if t.Labels == nil {
+		t.Labels = map[int][]string{}
+	}
+	for len(t.Labels[j]) <= i {
+		t.Labels[j] = append(t.Labels[j], "")
+	}
+	t.Labels[j][i] = s
+}
+
+// checkNumeric returns an error for the first text label in the table - for uses that need every
+// value to be a number.
+func (t *Table) checkNumeric() error {
+	for i := range t.Rows {
+		for j := range t.Columns {
+			if s := t.label(i, j); s != "" {
+				return fmt.Errorf("row %v: invalid number '%v' in column %v", i+1, s, t.Columns[j])
+			}
+		}
+	}
+	return nil
+}
+
+// Col returns the index of the named column or -1 if there is no such column.
+func (t *Table) Col(name string) int {
+	for i, col := range t.Columns {
+		if col == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ReadTable reads a table from the named file.  The format is determined by the file extension:
+// ".csv" (comma separated), ".tsv" (tab separated), ".npy" (a 1D or 2D NumPy array) or ".npz"
+// (a zip archive of NumPy arrays).  Text formats may have an optional header row of column names.
+// For npz archives, a single 2D array is read as the table's rows; otherwise every array must be
+// 1D with the same length and each becomes a column named after the array.
+func ReadTable(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return readDelimited(f, ',')
+	case ".tsv":
+		return readDelimited(f, '\t')
+	case ".npy":
+		return readNpyTable(f)
+	case ".npz":
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return readNpz(f, info.Size())
+	default:
+		return nil, fmt.Errorf("unsupported table format '%v'", ext)
+	}
+}
+
+// WriteTable writes t to the named file in the format indicated by the file extension (see
+// ReadTable).  For npz files, each column is written as a separate 1D array named after the
+// column.
+func WriteTable(path string, t *Table) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		err = writeDelimited(f, t, ',')
+	case ".tsv":
+		err = writeDelimited(f, t, '\t')
+	case ".npy":
+		err = writeNpy(f, t.Rows, len(t.Columns))
+	case ".npz":
+		err = writeNpz(f, t)
+	default:
+		err = fmt.Errorf("unsupported table format '%v'", ext)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readDelimited reads a text table with fields separated by delim - or by any whitespace if delim
+// is 0.  Lines starting with '#' are skipped and rows may have differing numbers of fields.  Empty
+// and "NA" fields are read as NaN (missing values) and other non-numeric fields as NaN with the
+// field as the value's label.  The first row is a header of column names if any of its fields
+// is not a number - empty header fields (e.g. a pandas index column) get default names.
+func readDelimited(r io.Reader, delim rune) (*Table, error) {
+	var records [][]string
+	if delim == 0 {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				records = append(records, strings.Fields(line))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		cr := csv.NewReader(r)
+		cr.Comma = delim
+		cr.Comment = '#'
+		cr.TrimLeadingSpace = true
+		cr.FieldsPerRecord = -1
+		var err error
+		if records, err = cr.ReadAll(); err != nil {
+			return nil, err
+		}
+	}
+
+	t := &Table{}
+	for i, rec := range records {
+		row := make([]float64, len(rec))
+		labels := map[int]string{}
+		for j, field := range rec {
+			field = strings.TrimSpace(field)
+			if field == "" || field == "NA" {
+				row[j] = math.NaN()
+				continue
+			}
+			var err error
+			if row[j], err = strconv.ParseFloat(field, 64); err != nil {
+				row[j], labels[j] = math.NaN(), field
+			}
+		}
+		if i == 0 && len(labels) > 0 {
+			t.Columns = defaultColumns(len(rec))
+			for j, name := range rec {
+				if name = strings.TrimSpace(name); name != "" {
+					t.Columns[j] = name
+				}
+			}
+			continue
+		}
+		for j, s := range labels {
+			t.setLabel(len(t.Rows), j, s)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if t.Columns == nil && len(t.Rows) > 0 {
+		t.Columns = defaultColumns(len(t.Rows[0]))
+	}
+	return t, nil
+}
+
+func writeDelimited(w io.Writer, t *Table, delim rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = delim
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	rec := make([]string, len(t.Columns))
+	for i, row := range t.Rows {
+		for j, val := range row {
+			rec[j] = t.label(i, j)
+			if rec[j] == "" {
+				rec[j] = strconv.FormatFloat(val, 'g', -1, 64)
+			}
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func defaultColumns(n int) []string {
+	cols := make([]string, n)
+	for i := range cols {
+		cols[i] = fmt.Sprintf("c%v", i)
+	}
+	return cols
+}
+
+var npyMagic = []byte("\x93NUMPY")
+
+var (
+	npyDescrRe   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortranRe = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShapeRe   = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+)
+
+// readNpy reads a 1D or 2D array in NumPy's .npy format.  The data is returned as row-major
+// values along with the array's shape.  1D arrays have a shape with a single entry.
+func readNpy(r io.Reader) (data []float64, shape []int, err error) {
+	var preamble [8]byte
+	if _, err := io.ReadFull(r, preamble[:]); err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(preamble[:6], npyMagic) {
+		return nil, nil, fmt.Errorf("not a npy file")
+	}
+
+	var hlen int
+	switch major := preamble[6]; major {
+	case 1:
+		var n uint16
+		err = binary.Read(r, binary.LittleEndian, &n)
+		hlen = int(n)
+	case 2, 3:
+		var n uint32
+		err = binary.Read(r, binary.LittleEndian, &n)
+		hlen = int(n)
+	default:
+		return nil, nil, fmt.Errorf("unsupported npy version %v", major)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	header := make([]byte, hlen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, err
+	}
+
+	m := npyDescrRe.FindSubmatch(header)
+	if m == nil {
+		return nil, nil, fmt.Errorf("npy header missing descr")
+	}
+	descr := string(m[1])
+	if len(descr) < 3 {
+		return nil, nil, fmt.Errorf("unsupported npy dtype '%v'", descr)
+	}
+	if m := npyFortranRe.FindSubmatch(header); m != nil && string(m[1]) == "True" {
+		return nil, nil, fmt.Errorf("fortran ordered npy arrays are not supported")
+	}
+	m = npyShapeRe.FindSubmatch(header)
+	if m == nil {
+		return nil, nil, fmt.Errorf("npy header missing shape")
+	}
+	size := 1
+	for _, dim := range strings.Split(string(m[1]), ",") {
+		if dim = strings.TrimSpace(dim); dim == "" {
+			continue
+		}
+		n, err := strconv.Atoi(dim)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid npy shape: %v", err)
+		}
+		shape = append(shape, n)
+		size *= n
+	}
+	if len(shape) == 0 || len(shape) > 2 {
+		return nil, nil, fmt.Errorf("unsupported npy array dimension %v", len(shape))
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if descr[0] == '>' {
+		order = binary.BigEndian
+	}
+	data = make([]float64, size)
+	switch descr[1:] {
+	case "f8":
+		err = binary.Read(r, order, data)
+	case "f4":
+		vals := make([]float32, size)
+		err = binary.Read(r, order, vals)
+		for i, v := range vals {
+			data[i] = float64(v)
+		}
+	case "i8":
+		vals := make([]int64, size)
+		err = binary.Read(r, order, vals)
+		for i, v := range vals {
+			data[i] = float64(v)
+		}
+	case "i4":
+		vals := make([]int32, size)
+		err = binary.Read(r, order, vals)
+		for i, v := range vals {
+			data[i] = float64(v)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported npy dtype '%v'", descr)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, shape, nil
+}
+
+func readNpyTable(r io.Reader) (*Table, error) {
+	data, shape, err := readNpy(r)
+	if err != nil {
+		return nil, err
+	}
+	return npyTable(data, shape), nil
+}
+
+// writeNpy writes rows as a little-endian float64 .npy array.  If ncols is 1, the array is 1D.
+func writeNpy(w io.Writer, rows [][]float64, ncols int) error {
+	shape := fmt.Sprintf("(%v, %v)", len(rows), ncols)
+	if ncols == 1 {
+		shape = fmt.Sprintf("(%v,)", len(rows))
+	}
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': False, 'shape': %v, }", shape)
+	// pad with spaces so the data starts on a 64 byte boundary and terminate with a newline
+	total := len(npyMagic) + 2 + 2 + len(header) + 1
+	header += strings.Repeat(" ", (64-total%64)%64) + "\n"
+
+	var buf bytes.Buffer
+	buf.Write(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	for _, row := range rows {
+		if len(row) != ncols {
+			return fmt.Errorf("npy rows must all have %v columns", ncols)
+		}
+		binary.Write(&buf, binary.LittleEndian, row)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readNpz(r io.ReaderAt, size int64) (*Table, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Table{}
+	var cols [][]float64
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, shape, err := readNpy(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", zf.Name, err)
+		}
+
+		if len(shape) == 2 {
+			if len(zr.File) != 1 {
+				return nil, fmt.Errorf("npz archives with 2D arrays must contain only one array")
+			}
+			return npyTable(data, shape), nil
+		}
+		if len(cols) > 0 && len(data) != len(cols[0]) {
+			return nil, fmt.Errorf("npz array '%v' has length %v, want %v", zf.Name, len(data), len(cols[0]))
+		}
+		t.Columns = append(t.Columns, strings.TrimSuffix(zf.Name, ".npy"))
+		cols = append(cols, data)
+	}
+
+	if len(cols) > 0 {
+		for i := range cols[0] {
+			row := make([]float64, len(cols))
+			for j := range cols {
+				row[j] = cols[j][i]
+			}
+			t.Rows = append(t.Rows, row)
+		}
+	}
+	return t, nil
+}
+
+// npyTable converts row-major 1D or 2D array data into a table.  1D arrays become a single column.
+func npyTable(data []float64, shape []int) *Table {
+	ncols := 1
+	if len(shape) == 2 {
+		ncols = shape[1]
+	}
+	t := &Table{Columns: defaultColumns(ncols)}
+	for i := 0; i < shape[0]; i++ {
+		t.Rows = append(t.Rows, data[i*ncols:(i+1)*ncols])
+	}
+	return t
+}
+
+func writeNpz(w io.Writer, t *Table) error {
+	zw := zip.NewWriter(w)
+	for j, name := range t.Columns {
+		col := make([][]float64, len(t.Rows))
+		for i, row := range t.Rows {
+			col[i] = []float64{row[j]}
+		}
+		f, err := zw.Create(name + ".npy")
+		if err != nil {
+			return err
+		}
+		if err := writeNpy(f, col, 1); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// SetVarName names the variable v.  Names are used as column names when reading and writing
+// tables.
+func (n *Network) SetVarName(v Variable, name string) {
+	if n.varNames == nil {
+		n.varNames = map[Variable]string{}
+	}
+	n.varNames[v] = name
+}
+
+// VarName returns the name of v - defaulting to v's String representation if it hasn't been
+// named.
+func (n *Network) VarName(v Variable) string {
+	if name, ok := n.varNames[v]; ok {
+		return name
+	}
+	return v.String()
+}
+
+// LoadTrainData adds the rows of the table in the named file to the network's training data.
+// If the table has columns named for all the network's input variables, input (and any
+// available target) values are taken from those columns.  Otherwise the first columns are the
+// input values in Vars order followed by target values in TargetVars order.
+func (n *Network) LoadTrainData(path string) error {
+	t, err := ReadTable(path)
+	if err != nil {
+		return err
+	} else if err := t.checkNumeric(); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+
+	incols, tcols := n.tableCols(t)
+	for i, row := range t.Rows {
+		x := make([]float64, len(incols))
+		for j, col := range incols {
+			if col >= len(row) {
+				return fmt.Errorf("%v: row %v has too few columns", path, i+1)
+			}
+			x[j] = row[col]
+		}
+		var target []float64
+		for _, col := range tcols {
+			val := math.NaN()
+			if col >= 0 && col < len(row) {
+				val = row[col]
+			}
+			target = append(target, val)
+		}
+		n.AddSample(x, target)
+	}
+	return nil
+}
+
+// tableCols returns the table column indices holding values for the network's input and target
+// variables.  Target columns that are absent from the table are -1.
+func (n *Network) tableCols(t *Table) (incols, tcols []int) {
+	byName := true
+	for _, v := range n.Vars {
+		if t.Col(n.VarName(v)) < 0 {
+			byName = false
+		}
+	}
+
+	for i, v := range n.Vars {
+		if byName {
+			incols = append(incols, t.Col(n.VarName(v)))
+		} else {
+			incols = append(incols, i)
+		}
+	}
+	for i, v := range n.TargetVars {
+		if byName {
+			tcols = append(tcols, t.Col(n.VarName(v)))
+		} else {
+			tcols = append(tcols, len(n.Vars)+i)
+		}
+	}
+	return incols, tcols
+}
+
+// ReadGrid reads evaluation points (input variable values) from the table in the named file
+// using the same column rules as LoadTrainData.
+func (n *Network) ReadGrid(path string) ([][]float64, error) {
+	t, err := ReadTable(path)
+	if err != nil {
+		return nil, err
+	} else if err := t.checkNumeric(); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	incols, _ := n.tableCols(t)
+	pts := make([][]float64, len(t.Rows))
+	for i, row := range t.Rows {
+		pts[i] = make([]float64, len(incols))
+		for j, col := range incols {
+			if col >= len(row) {
+				return nil, fmt.Errorf("%v: row %v has too few columns", path, i+1)
+			}
+			pts[i][j] = row[col]
+		}
+	}
+	return pts, nil
+}
+
+// EvalTable evaluates all the network's outputs at each of the given points and returns a table
+// with a column for each input variable followed by a column for each output field.
+func (n *Network) EvalTable(pts [][]float64) *Table {
+	t := &Table{}
+	for _, v := range n.Vars {
+		t.Columns = append(t.Columns, n.VarName(v))
+	}
+	t.Columns = append(t.Columns, n.FieldNames()...)
+	for _, pt := range pts {
+		t.Rows = append(t.Rows, append(append([]float64{}, pt...), n.Eval(pt)...))
+	}
+	return t
+}
+
+// WriteSolution evaluates the network at the given points and writes the results to the named
+// file (see EvalTable and WriteTable).
+func (n *Network) WriteSolution(path string, pts [][]float64) error {
+	return WriteTable(path, n.EvalTable(pts))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTableRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	want := &Table{
+		Columns: []string{"x", "t", "u"},
+		Rows:    [][]float64{{0, 0, 1.5}, {0.5, 0.25, -2}, {1, 1, 1e-9}},
+	}
+
+	for _, ext := range []string{".csv", ".tsv", ".npz"} {
+		path := filepath.Join(dir, "table"+ext)
+		if err := WriteTable(path, want); err != nil {
+			t.Fatalf("%v: %v", ext, err)
+		}
+		got, err := ReadTable(path)
+		if err != nil {
+			t.Fatalf("%v: %v", ext, err)
+		}
+		if !tablesEqual(got, want) {
+			t.Errorf("%v: want %+v, got %+v", ext, want, got)
+		}
+	}
+
+	// npy arrays don't store column names
+	path := filepath.Join(dir, "table.npy")
+	if err := WriteTable(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Columns = want.Columns
+	if !tablesEqual(got, want) {
+		t.Errorf(".npy: want %+v, got %+v", want, got)
+	}
+}
+
+func TestReadNpyDtypes(t *testing.T) {
+	// a 1D float32 array as written by numpy.save
+	var buf bytes.Buffer
+	header := "{'descr': '<f4', 'fortran_order': False, 'shape': (3,), }"
+	header += string(bytes.Repeat([]byte(" "), 128-10-len(header)-1)) + "\n"
+	buf.Write(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	binary.Write(&buf, binary.LittleEndian, []float32{1, 2.5, -3})
+
+	data, shape, err := readNpy(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shape) != 1 || shape[0] != 3 {
+		t.Errorf("want shape [3], got %v", shape)
+	}
+	want := []float64{1, 2.5, -3}
+	for i := range want {
+		if data[i] != want[i] {
+			t.Errorf("want data %v, got %v", want, data)
+			break
+		}
+	}
+}
+
+func TestNetworkTables(t *testing.T) {
+	dir := t.TempDir()
+	var net Network
+	in1, x := net.NewInput()
+	net.NewField("u").PullFrom(in1)
+	target := net.NewTarget()
+	net.SetVarName(x, "x")
+	net.SetVarName(target, "u_obs")
+
+	// columns in a different order than the network's variables
+	path := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(path, []byte("u_obs,x\n2,1\n3,4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.LoadTrainData(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(net.TrainData) != 2 || net.TrainData[1][0] != 4 || net.Targets[1][0] != 3 {
+		t.Errorf("want samples x=[1 4] u_obs=[2 3], got %v and %v", net.TrainData, net.Targets)
+	}
+
+	net.initState()
+	pts, err := net.ReadGrid(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := net.EvalTable(pts)
+	if len(sol.Columns) != 2 || sol.Columns[0] != "x" || sol.Columns[1] != "u" {
+		t.Errorf("want columns [x u], got %v", sol.Columns)
+	}
+	if len(sol.Rows) != 2 || sol.Rows[1][0] != 4 {
+		t.Errorf("want solution rows at x=[1 4], got %v", sol.Rows)
+	}
+}
+
+func tablesEqual(a, b *Table) bool {
+	if len(a.Columns) != len(b.Columns) || len(a.Rows) != len(b.Rows) {
+		return false
+	}
+	for i := range a.Columns {
+		if a.Columns[i] != b.Columns[i] {
+			return false
+		}
+	}
+	for i := range a.Rows {
+		if len(a.Rows[i]) != len(b.Rows[i]) {
+			return false
+		}
+		for j := range a.Rows[i] {
+			if a.Rows[i][j] != b.Rows[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestTableLabels(t *testing.T) {
+	want := &Table{Columns: []string{"method", "loss"}, Rows: [][]float64{{1, 0.5}, {2, 0.25}}}
+	want.setLabel(0, 0, "bfgs")
+	want.setLabel(1, 0, "cg")
+	path := filepath.Join(t.TempDir(), "labels.csv")
+	if err := WriteTable(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.label(0, 0) != "bfgs" || got.label(1, 0) != "cg" || got.Rows[1][1] != 0.25 {
+		t.Errorf("want labels bfgs and cg, got %+v", got)
+	}
+	if err := got.checkNumeric(); err == nil {
+		t.Errorf("want error for a labeled table, got nil")
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	got, err := readDelimited(strings.NewReader(",x,1e3\n0,1,2\n"), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Table{Columns: []string{"c0", "x", "1e3"}, Rows: [][]float64{{0, 1, 2}}}
+	if !tablesEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
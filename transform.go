@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Normalize returns f affinely mapped from [lo, hi] to [-1, 1] - e.g. to keep tanh neurons fed by
+// physical coordinates out of saturation.  Degenerate ranges (lo == hi, e.g. dummy inputs) are
+// left unscaled.  The mapping is built from ordinary nodes so derivatives w.r.t. f's variables
+// (and hence PDE residuals) stay in physical units.
+func Normalize(f Func, lo, hi float64) Func {
+	if lo == hi {
+		return f
+	}
+	return Sum{Mult{Constant(2 / (hi - lo)), f}, Constant(-(hi + lo) / (hi - lo))}
+}
+
+// Denormalize is the inverse of Normalize mapping f from [-1, 1] to [lo, hi] - e.g. to scale a
+// network output to the expected range of a solution field.
+func Denormalize(f Func, lo, hi float64) Func {
+	return Sum{Mult{Constant((hi - lo) / 2), f}, Constant((hi + lo) / 2)}
+}
+
+// Normalize replaces each of the neuron's inputs with its value normalized from [lo, hi] to
+// [-1, 1] (see Normalize) and returns the neuron.  It is intended for input neurons - e.g.
+// net.NewInput() followed by in.Normalize(0, 5) for a coordinate on [0, 5].
+func (nr *Neuron) Normalize(lo, hi float64) *Neuron {
+	for i, in := range nr.Inputs {
+		nr.Inputs[i] = Normalize(in, lo, hi)
+	}
+	return nr
+}
+
+// FourierFeatures returns the embedding sin(2*pi*b.x), cos(2*pi*b.x) of the inputs x for each
+// frequency vector b in freqs.  Feeding the features to the network instead of (or along with)
+// the raw inputs helps it represent high frequency solutions.  Inputs should usually be
+// normalized first.
+func FourierFeatures(inputs []Func, freqs [][]float64) []Func {
+	var feats []Func
+	for _, b := range freqs {
+		var arg Sum
+		for i, in := range inputs {
+			if b[i] != 0 {
+				arg = append(arg, Mult{Constant(2 * math.Pi * b[i]), in})
+			}
+		}
+		feats = append(feats, Sin{arg}, Cos{arg})
+	}
+	return feats
+}
+
+// RandomFrequencies returns m frequency vectors for FourierFeatures of dim inputs with components
+// drawn from a normal distribution with standard deviation sigma.
+func RandomFrequencies(rng *rand.Rand, m, dim int, sigma float64) [][]float64 {
+	freqs := make([][]float64, m)
+	for k := range freqs {
+		freqs[k] = make([]float64, dim)
+		for i := range freqs[k] {
+			freqs[k][i] = sigma * rng.NormFloat64()
+		}
+	}
+	return freqs
+}
+
+// NewFourierInputs creates an input neuron (see NewInputFunc) for each of the Fourier features
+// of inputs with the given frequencies.
+func (n *Network) NewFourierInputs(inputs []Func, freqs [][]float64) []*Neuron {
+	var neurons []*Neuron
+	for _, f := range FourierFeatures(inputs, freqs) {
+		neurons = append(neurons, n.NewInputFunc(f))
+	}
+	return neurons
+}
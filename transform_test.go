@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	n := Normalize(x, 1, 5)
+	for _, tt := range []struct{ in, want float64 }{{1, -1}, {3, 0}, {5, 1}} {
+		if got := n.Val([]float64{tt.in}); math.Abs(got-tt.want) > 1e-15 {
+			t.Errorf("Normalize(%v): want %v, got %v", tt.in, tt.want, got)
+		}
+	}
+	if got := n.Partial(x).Simplify(); got != Constant(0.5) {
+		t.Errorf("d/dx: want 0.5, got %v", got)
+	}
+	if got := Denormalize(n, 1, 5).Val([]float64{4.2}); math.Abs(got-4.2) > 1e-14 {
+		t.Errorf("Denormalize(Normalize(4.2)): got %v", got)
+	}
+	if got := Normalize(x, 2, 2); got != Func(x) {
+		t.Errorf("degenerate range: want x unscaled, got %v", got)
+	}
+}
+
+func TestFourierFeatures(t *testing.T) {
+	freqs := [][]float64{{1, 0}, {0.5, -2}}
+	feats := FourierFeatures([]Func{x, y}, freqs)
+	if len(feats) != 4 {
+		t.Fatalf("want 4 features, got %v", len(feats))
+	}
+	pt := []float64{0.3, 0.7}
+	arg := 2 * math.Pi * (0.5*0.3 - 2*0.7)
+	if got, want := feats[3].Val(pt), math.Cos(arg); math.Abs(got-want) > 1e-14 {
+		t.Errorf("cos feature: want %v, got %v", want, got)
+	}
+	for _, f := range feats {
+		if err := CheckGradient(f, pt, []Variable{x, y}); err != nil {
+			t.Errorf("%v: %v", f, err)
+		}
+	}
+	if got := RandomFrequencies(rand.New(rand.NewSource(1)), 3, 2, 10); len(got) != 3 || len(got[0]) != 2 {
+		t.Errorf("want 3x2 frequencies, got %v", got)
+	}
+}
+
+// TestTransformedDerivatives checks that derivatives w.r.t. the physical coordinates flow
+// through normalized and Fourier feature inputs.
+func TestTransformedDerivatives(t *testing.T) {
+	var net Network
+	in1, xv := net.NewInput()
+	in2, yv := net.NewInput()
+	in1.Normalize(0, 5)
+	in2.Normalize(-1, 3)
+	inputs := []*Neuron{in1, in2}
+	normalized := []Func{Normalize(xv, 0, 5), Normalize(yv, -1, 3)}
+	freqs := RandomFrequencies(rand.New(rand.NewSource(1)), 2, 2, 1)
+	inputs = append(inputs, net.NewFourierInputs(normalized, freqs)...)
+	hidden := net.NewLayers(inputs, 3, 1)
+	u := Denormalize(net.NewField("u").PullFrom(hidden...), -10, 10)
+	net.RandomizeWeights(rand.New(rand.NewSource(2)))
+
+	net.initState()
+	state := append([]float64{}, net.state...)
+	state[int(xv)], state[int(yv)] = 4.1, 0.6
+	vars := []Variable{xv, yv}
+	for _, f := range []Func{u, u.Partial(xv), u.Partial(yv)} {
+		if err := CheckGradient(f, state, vars); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := CheckGradient(u, state, net.Weights); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProblemSpecTransforms(t *testing.T) {
+	const src = `
+inputs:
+  - {name: x, min: 0, max: 4}
+fields: [u]
+equations:
+  - {residual: "d(u, x) - 1"}
+network: {normalize: true, fourier: 2, fourier_scale: 0.5, output: {min: 0, max: 10}}
+`
+	path := filepath.Join(t.TempDir(), "p.yaml")
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadProblem(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.Build(Config{Width: 2})
+	// x and dummy input neurons plus 4 Fourier feature neurons, each with 1 weight, 6x2 hidden
+	// layer weights and 2 output weights
+	if got, want := len(s.Net.Weights), 6+12+2; got != want {
+		t.Errorf("want %v weights, got %v", want, got)
+	}
+	if err := s.Net.CheckCostGradient(s.Net.trainableVals()); err != nil {
+		t.Error(err)
+	}
+	// with zero weights the raw output is 0 - the middle of the output range
+	s.Net.initState()
+	for _, w := range s.Net.Weights {
+		s.Net.state[int(w)] = 0
+	}
+	if got := s.Net.EvalFunc(s.Fields[0], []float64{1, 1}); got != 5 {
+		t.Errorf("want scaled output 5, got %v", got)
+	}
+}
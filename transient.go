@@ -0,0 +1,131 @@
+package main
+
+// NewTimeInput creates a new input neuron/variable pair like NewInput and marks the variable as
+// the time coordinate of the problem.
+func (n *Network) NewTimeInput() (*Neuron, Variable) {
+	neuron, v := n.NewInput()
+	n.timeVar = v
+	n.hasTime = true
+	return neuron, v
+}
+
+// TimeVar returns the network's time variable and true if one has been created with
+// NewTimeInput.  Otherwise ok is false and the problem is steady-state.
+func (n *Network) TimeVar() (v Variable, ok bool) { return n.timeVar, n.hasTime }
+
+// SpaceVars returns all the network's input variables except for the time variable.
+func (n *Network) SpaceVars() []Variable {
+	var vars []Variable
+	for _, v := range n.Vars {
+		if n.hasTime && v == n.timeVar {
+			continue
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// Condition is a residual that only applies at points where the Where func returns true - e.g.
+// boundary and initial conditions.
+type Condition struct {
+	Name     string
+	Where    func(x []float64) bool
+	Residual Func
+}
+
+// Func returns the condition's residual as a function that is zero everywhere the condition does
+// not apply.
+func (c Condition) Func() Func {
+	return Branch(func(x []float64) Func {
+		if c.Where(x) {
+			return c.Residual
+		}
+		return Constant(0)
+	})
+}
+
+// AddCondition adds the condition's residual to the system with the given weight.
+func (s *System) AddCondition(c Condition, weight float64) { s.Add(c.Name, c.Func(), weight) }
+
+// DirichletBC returns a condition requiring u == value wherever the where func returns true.
+func DirichletBC(name string, u, value Func, where func(x []float64) bool) Condition {
+	return Condition{Name: name, Where: where, Residual: Sum{u, Negative(value)}}
+}
+
+// NeumannBC returns a condition requiring du/dv == flux wherever the where func returns true.
+func NeumannBC(name string, u Func, v Variable, flux Func, where func(x []float64) bool) Condition {
+	return Condition{Name: name, Where: where, Residual: Sum{u.Partial(v), Negative(flux)}}
+}
+
+// InitialValue returns a condition with the given name requiring u == value at time t == t0.
+func InitialValue(name string, u Func, t Variable, t0 float64, value Func) Condition {
+	return Condition{
+		Name:     name,
+		Where:    func(x []float64) bool { return x[int(t)] == t0 },
+		Residual: Sum{u, Negative(value)},
+	}
+}
+
+// InitialRate returns a condition with the given name requiring du/dt == rate at time t == t0.
+// This is necessary in addition to InitialValue for problems that are second order in time
+// (e.g. the wave equation).
+func InitialRate(name string, u Func, t Variable, t0 float64, rate Func) Condition {
+	return Condition{
+		Name:     name,
+		Where:    func(x []float64) bool { return x[int(t)] == t0 },
+		Residual: Sum{u.Partial(t), Negative(rate)},
+	}
+}
+
+// HeatResidual returns the residual du/dt - alpha*laplace(u) of the heat (diffusion) equation
+// with diffusivity alpha.
+func HeatResidual(u Func, t Variable, alpha Func, space ...Variable) Func {
+	return Sum{u.Partial(t), Negative(Mult{alpha, Laplace(u, space...)})}
+}
+
+// WaveResidual returns the residual d^2u/dt^2 - c^2*laplace(u) of the wave equation with wave
+// speed c.
+func WaveResidual(u Func, t Variable, c Func, space ...Variable) Func {
+	return Sum{u.Partial(t).Partial(t), Negative(Mult{c, c, Laplace(u, space...)})}
+}
+
+// AdvectionResidual returns the residual du/dt + vel . grad(u) of the advection equation where
+// vel[i] is the velocity component along space[i].
+func AdvectionResidual(u Func, t Variable, vel []Func, space ...Variable) Func {
+	return Sum{u.Partial(t), DirDeriv(u, vel, space...)}
+}
+
+// Grid returns a regular grid of points spanning [lo[i], hi[i]] (inclusive) with n[i] points
+// along each dimension i.  Dimensions with a single point are fixed at lo[i] which is convenient
+// for dummy inputs.  Including time as one of the dimensions produces space-time collocation
+// points that include the initial time slice.
+func Grid(lo, hi []float64, n ...int) [][]float64 {
+	if len(lo) != len(hi) || len(lo) != len(n) {
+		panic("Grid: mismatched dimensions")
+	}
+	var pts [][]float64
+	for _, perm := range gridIndices(n) {
+		pt := make([]float64, len(perm))
+		for i, j := range perm {
+			pt[i] = lo[i]
+			if n[i] > 1 {
+				pt[i] += float64(j) / float64(n[i]-1) * (hi[i] - lo[i])
+			}
+		}
+		pts = append(pts, pt)
+	}
+	return pts
+}
+
+func gridIndices(n []int) [][]int {
+	if len(n) == 0 {
+		return [][]int{{}}
+	}
+	var all [][]int
+	for _, rest := range gridIndices(n[1:]) {
+		for i := 0; i < n[0]; i++ {
+			all = append(all, append([]int{i}, rest...))
+		}
+	}
+	return all
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGrid(t *testing.T) {
+	pts := Grid([]float64{0, 2, 1}, []float64{1, 4, 1}, 3, 2, 1)
+	if len(pts) != 6 {
+		t.Fatalf("want 6 points, got %v", len(pts))
+	}
+	want := [][]float64{{0, 2, 1}, {.5, 2, 1}, {1, 2, 1}, {0, 4, 1}, {.5, 4, 1}, {1, 4, 1}}
+	for i := range want {
+		for j := range want[i] {
+			if pts[i][j] != want[i][j] {
+				t.Errorf("point %v: want %v, got %v", i, want[i], pts[i])
+				break
+			}
+		}
+	}
+}
+
+func TestTransientResiduals(t *testing.T) {
+	var xv, tv Variable = 0, 1
+	// u = x^2 + 2*t satisfies du/dt = laplace(u)
+	u2 := Sum{&Pow{xv, Constant(2)}, Mult{Constant(2), tv}}
+	// u = (x - t)^2 satisfies the advection equation with unit velocity and the wave equation
+	// with unit speed
+	u3 := &Pow{Sum{xv, Negative(tv)}, Constant(2)}
+
+	tests := []struct {
+		Name     string
+		Residual Func
+	}{
+		{"heat", HeatResidual(u2, tv, Constant(1), xv)},
+		{"wave", WaveResidual(u3, tv, Constant(1), xv)},
+		{"advection", AdvectionResidual(u3, tv, []Func{Constant(1)}, xv)},
+	}
+
+	for _, test := range tests {
+		for _, pt := range Grid([]float64{.5, 0}, []float64{2, 1}, 4, 4) {
+			if got := test.Residual.Val(pt); math.Abs(got) > 1e-10 {
+				t.Errorf("%v residual at %v: want 0, got %v", test.Name, pt, got)
+			}
+		}
+	}
+}
+
+func TestInitialConditions(t *testing.T) {
+	var xv, tv Variable = 0, 1
+	u := Mult{xv, Sum{tv, Constant(1)}}
+
+	valCond := InitialValue("u0", u, tv, 0, xv)
+	rateCond := InitialRate("du0", u, tv, 0, Constant(3))
+	if valCond.Name != "u0" || rateCond.Name != "du0" {
+		t.Errorf("want names u0 and du0, got %v and %v", valCond.Name, rateCond.Name)
+	}
+	val, rate := valCond.Func(), rateCond.Func()
+	tests := []struct {
+		Name string
+		Fn   Func
+		X    []float64
+		Want float64
+	}{
+		{"value at t0", val, []float64{2, 0}, 0},
+		{"value after t0", val, []float64{2, 1}, 0},
+		{"rate at t0", rate, []float64{2, 0}, -1},
+		{"rate after t0", rate, []float64{2, 1}, 0},
+	}
+	for _, test := range tests {
+		if got := test.Fn.Val(test.X); math.Abs(got-test.Want) > 1e-10 {
+			t.Errorf("%v: want %v, got %v", test.Name, test.Want, got)
+		}
+	}
+}
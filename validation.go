@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// Validation holds out points from training and monitors the loss on them during training to
+// detect overfitting.  If Patience is nonzero, training stops early once the validation loss
+// has not improved for Patience consecutive checks and the weights with the best validation
+// loss are restored.
+type Validation struct {
+	// Data and Targets are the held-out points and their observations (see Network.Targets).
+	Data    [][]float64
+	Targets [][]float64
+	// Loss, if non-nil, is used to report the validation loss of each term (e.g. residual and
+	// data misfit) separately.  It should match the network's CostFunc.
+	Loss *Loss
+	// Every is the number of major iterations between validation checks (default 1).
+	Every int
+	// Patience is the number of checks without improvement after which training stops.  Zero
+	// disables early stopping.
+	Patience int
+	// MinDelta is the minimum decrease of the validation loss that counts as an improvement.
+	MinDelta float64
+	// Out, if non-nil, receives a report for every check.
+	Out io.Writer
+
+	// History is the validation loss at every check and BestIteration the iteration (see
+	// Network.Iterations) with the lowest validation loss.
+	History       []float64
+	BestIteration int
+
+	best     []float64
+	bestLoss float64
+	bad      int
+	stopped  bool
+}
+
+// SplitValidation randomly moves the given fraction (0 <= frac < 1) of the network's training
+// points (and their targets) into a new validation set which is returned and stored in
+// n.Validation.  Quadrature weighted training points (see PointWeights) can't be split.
+func (n *Network) SplitValidation(frac float64, rng *rand.Rand) (*Validation, error) {
+	if !(frac >= 0 && frac < 1) {
+		return nil, fmt.Errorf("validation fraction %v is outside [0, 1)", frac)
+	} else if n.PointWeights != nil {
+		return nil, fmt.Errorf("can't split weighted (quadrature) training points for validation")
+	}
+	nval := int(math.Round(frac * float64(len(n.TrainData))))
+	held := map[int]bool{}
+	for _, i := range rng.Perm(len(n.TrainData))[:nval] {
+		held[i] = true
+	}
+
+	v := &Validation{}
+	var data, targets [][]float64
+	for i, pt := range n.TrainData {
+		var target []float64
+		if i < len(n.Targets) {
+			target = n.Targets[i]
+		}
+		if held[i] {
+			v.Data = append(v.Data, pt)
+			v.Targets = append(v.Targets, target)
+		} else {
+			data = append(data, pt)
+			targets = append(targets, target)
+		}
+	}
+	n.TrainData = data
+	if n.Targets != nil {
+		n.Targets = targets
+	}
+	n.Validation = v
+	return v, nil
+}
+
+// ValidationLoss returns the mean of the network's CostFunc over the validation points at the
+// current weights.
+func (n *Network) ValidationLoss() float64 {
+	return n.meanOver(n.CostFunc, n.Validation.Data, n.Validation.Targets)
+}
+
+func (n *Network) meanOver(f Func, pts, targets [][]float64) float64 {
+	n.initState()
+	tot := 0.0
+	for i := range pts {
+		n.setPointFrom(pts, targets, i)
+		tot += f.Val(n.state)
+	}
+	return tot / float64(len(pts))
+}
+
+// reset prepares the validation for a new training run.
+func (v *Validation) reset() {
+	v.best, v.bestLoss, v.bad, v.stopped = nil, math.Inf(1), 0, false
+}
+
+// check evaluates the validation loss at the trainable values x and updates the early stopping
+// state.
+func (v *Validation) check(n *Network, x []float64) {
+	every := v.Every
+	if every == 0 {
+		every = 1
+	}
+	if n.Iterations%every != 0 || len(v.Data) == 0 {
+		return
+	}
+
+	for i, w := range n.trainable() {
+		n.state[int(w)] = x[i]
+	}
+	loss := n.ValidationLoss()
+	v.History = append(v.History, loss)
+	if v.best == nil || loss < v.bestLoss-v.MinDelta {
+		v.best, v.bestLoss, v.bad = append([]float64{}, x...), loss, 0
+		v.BestIteration = n.Iterations
+	} else {
+		v.bad++
+		v.stopped = v.Patience > 0 && v.bad >= v.Patience
+	}
+
+	if v.Out != nil {
+		fmt.Fprintf(v.Out, "Validation (iteration %v): loss %v (best %v at iteration %v)\n", n.Iterations, loss, v.bestLoss, v.BestIteration)
+		if v.Loss != nil {
+			for _, term := range v.Loss.Terms {
+				fmt.Fprintf(v.Out, "    %v: %v\n", term.Name, n.meanOver(term.Func, v.Data, v.Targets))
+			}
+		}
+		if v.stopped {
+			fmt.Fprintf(v.Out, "Validation: no improvement for %v checks - stopping early\n", v.bad)
+		}
+	}
+}
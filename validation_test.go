@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitValidation(t *testing.T) {
+	var net Network
+	net.NewInput()
+	net.NewTarget()
+	for i := 0; i < 10; i++ {
+		net.AddSample([]float64{float64(i)}, []float64{float64(10 * i)})
+	}
+	if _, err := net.SplitValidation(1.5, rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("want error for a validation fraction > 1, got nil")
+	}
+	v, err := net.SplitValidation(0.3, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Data) != 3 || len(net.TrainData) != 7 || len(net.Targets) != 7 || net.Validation != v {
+		t.Fatalf("got %v validation and %v training points, want 3 and 7", len(v.Data), len(net.TrainData))
+	}
+	for i, pt := range v.Data {
+		if v.Targets[i][0] != 10*pt[0] {
+			t.Errorf("validation point %v has target %v, want %v", pt, v.Targets[i][0], 10*pt[0])
+		}
+	}
+	for i, pt := range net.TrainData {
+		if net.Targets[i][0] != 10*pt[0] {
+			t.Errorf("training point %v has target %v, want %v", pt, net.Targets[i][0], 10*pt[0])
+		}
+	}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	// fit u(x) = x^2 at a few training points with a network flexible enough to overfit them
+	var net Network
+	in, x := net.NewInput()
+	dummyin, _ := net.NewInput()
+	hidden := net.NewLayers([]*Neuron{in, dummyin}, 4, 1)
+	u := net.NewField("u").PullFrom(hidden...)
+	target := net.NewTarget()
+	net.CostFunc = DataMisfit(u, target, MSE)
+	for _, xv := range []float64{0, 0.2, 0.4, 0.6, 0.8, 1} {
+		net.AddSample([]float64{xv, 1}, []float64{xv * xv})
+	}
+	_ = x
+
+	var loss Loss
+	loss.Add("data", net.CostFunc, 1)
+	var buf bytes.Buffer
+	v := &Validation{Data: [][]float64{{0.1, 1}, {0.5, 1}, {0.9, 1}}, Targets: [][]float64{{0.01}, {0.25}, {0.81}}, Loss: &loss, Patience: 2, Out: &buf}
+	v.MinDelta = math.Inf(1) // nothing ever counts as an improvement
+	net.Validation = v
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+	net.CheckpointPath = checkpoint
+	net.RandomizeWeights(rand.New(rand.NewSource(1)))
+	start := net.trainableVals()
+	net.Train()
+	net.CheckpointPath = ""
+
+	// the very first check is the best and training stops two checks later
+	if net.Iterations != 2 || len(v.History) != 3 || v.BestIteration != 0 {
+		t.Errorf("got %v iterations, %v checks and best iteration %v, want 2, 3 and 0", net.Iterations, len(v.History), v.BestIteration)
+	}
+	for i, w := range net.trainableVals() {
+		if w != start[i] {
+			t.Fatalf("best (initial) weights were not restored")
+		}
+	}
+	if got := net.ValidationLoss(); got != v.History[0] {
+		t.Errorf("restored validation loss %v != best %v", got, v.History[0])
+	}
+	// the final checkpoint holds the restored weights too
+	net.RandomizeWeights(rand.New(rand.NewSource(2)))
+	if err := net.LoadWeights(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range net.trainableVals() {
+		if w != start[i] {
+			t.Fatalf("checkpoint weights differ from the restored best weights")
+		}
+	}
+	if out := buf.String(); !strings.Contains(out, "data: ") || !strings.Contains(out, "stopping early") {
+		t.Errorf("unexpected report:\n%v", out)
+	}
+
+	// without a patience, training continues and keeps monitoring
+	v.Patience, v.MinDelta, v.Out = 0, 0, nil
+	net.MaxIterations = net.Iterations + 4
+	net.Train()
+	if len(v.History) < 5 {
+		t.Errorf("got %v validation checks, want training to continue past the patience", len(v.History))
+	}
+}